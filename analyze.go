@@ -0,0 +1,299 @@
+package crinex
+
+import (
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ---------------------------------------------------
+// Observation statistics
+// ---------------------------------------------------
+
+// Summary is the observation statistics of a file reported by Analyze.
+type Summary struct {
+	CRINEXVersion string    `json:"crinex_version"`
+	RINEXVersion  string    `json:"rinex_version"`
+	First         time.Time `json:"first"`
+	Last          time.Time `json:"last"`
+	Epochs        int       `json:"epochs"`
+
+	HeaderInterval time.Duration `json:"header_interval"` // INTERVAL, 0 if not given
+	Interval       time.Duration `json:"interval"`        // modal spacing of the epochs
+	Gaps           []Gap         `json:"gaps"`
+
+	// number of epochs (flags 0 and 1) and special events (flags 2-6) for
+	// every epoch flag
+	EpochFlags map[int]int `json:"epoch_flags"`
+
+	// receiver clock offset in seconds, over the epochs with the clock
+	ClockEpochs int     `json:"clock_epochs"`
+	ClockMin    float64 `json:"clock_min"`
+	ClockMax    float64 `json:"clock_max"`
+
+	LLI     int             `json:"lli"` // observations with the LLI set
+	Systems []SystemSummary `json:"systems"`
+}
+
+// Gap is a period without epochs longer than the interval.
+type Gap struct {
+	Start   time.Time `json:"start"`   // last epoch before the gap
+	End     time.Time `json:"end"`     // first epoch after the gap
+	Missing int       `json:"missing"` // number of missing epochs
+}
+
+// SystemSummary is the statistics of a satellite system.
+type SystemSummary struct {
+	System     string       `json:"system"`
+	Epochs     int          `json:"epochs"` // epochs with any satellite of the system
+	Obs        []ObsSummary `json:"obs"`    // over all the satellites of the system
+	Satellites []SatSummary `json:"satellites"`
+}
+
+// SatSummary is the statistics of a satellite.
+type SatSummary struct {
+	Sat    string       `json:"sat"`
+	Epochs int          `json:"epochs"` // epochs with the satellite
+	First  time.Time    `json:"first"`
+	Last   time.Time    `json:"last"`
+	Obs    []ObsSummary `json:"obs"`
+}
+
+// ObsSummary is the statistics of an observation code.
+type ObsSummary struct {
+	Code         string  `json:"code"`
+	Count        int     `json:"count"`        // observations not missing
+	Completeness float64 `json:"completeness"` // Count over the epochs of the satellite(s)
+	LLI          int     `json:"lli"`          // observations with the LLI set
+}
+
+// Analyze decodes the Hatanaka RINEX file read from r in one pass, and
+// returns the observation statistics built on Scanner.Data and
+// Scanner.ClockOffset.
+//
+// Gaps are the spacings longer than 1.5 times the interval, given by the
+// header INTERVAL or by the modal spacing of the epochs if not given.
+func Analyze(r io.Reader) (sum Summary, err error) {
+	s, err := NewScanner(r)
+	if err != nil {
+		return sum, err
+	}
+	if err := s.ParseHeader(); err != nil {
+		return sum, err
+	}
+	lines := headerLines(s.Header())
+
+	sum.CRINEXVersion = s.ver
+	if l, ok := findHeaderLine(lines, labelRinexVersion); ok {
+		sum.RINEXVersion = trimHeaderValue(l, 0, 20)
+	}
+	if v, ok := headerInterval(lines); ok {
+		sum.HeaderInterval = time.Duration(v * float64(time.Second))
+	}
+	sum.EpochFlags = make(map[int]int)
+	sum.ClockMin, sum.ClockMax = math.Inf(1), math.Inf(-1)
+
+	var (
+		epochs  []time.Time
+		systems = make(map[string]*sysStats)
+		sats    = make(map[string]*satStats)
+	)
+	for s.ScanEpoch() {
+		epoch := s.Epoch()
+		epochs = append(epochs, epoch)
+
+		for _, ev := range s.Events() {
+			sum.EpochFlags[ev.Flag]++
+		}
+		sum.EpochFlags[s.EpochFlag()]++
+
+		if clk := s.ClockOffset(); !math.IsNaN(clk) {
+			sum.ClockEpochs++
+			sum.ClockMin = math.Min(sum.ClockMin, clk)
+			sum.ClockMax = math.Max(sum.ClockMax, clk)
+		}
+
+		seen := make(map[string]bool) // systems in the epoch
+		for _, o := range s.Data() {
+			if len(o.ObsData) == 0 {
+				// invalid satellite
+				continue
+			}
+			sys := o.SatId[:1]
+			if sys == " " {
+				sys = "G"
+			}
+
+			st, ok := sats[o.SatId]
+			if !ok {
+				st = &satStats{SatSummary: SatSummary{Sat: o.SatId, First: epoch}}
+				sats[o.SatId] = st
+			}
+			st.Epochs++
+			st.Last = epoch
+
+			if !seen[sys] {
+				seen[sys] = true
+				if systems[sys] == nil {
+					systems[sys] = &sysStats{}
+				}
+				systems[sys].epochs++
+			}
+
+			codes := s.obsTypes[o.SatId[:1]]
+			for j, d := range o.ObsData {
+				if j >= len(codes) {
+					break
+				}
+				ok := !math.IsNaN(d.Data)
+				l, _ := d.LossOfLock()
+				lli := ok && l != 0
+				st.add(codes[j], ok, lli)
+				if lli {
+					sum.LLI++
+				}
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return sum, err
+	}
+
+	if sum.ClockEpochs == 0 {
+		sum.ClockMin, sum.ClockMax = 0, 0
+	}
+
+	// time span and gaps
+	sum.Epochs = len(epochs)
+	if len(epochs) > 0 {
+		sum.First, sum.Last = epochs[0], epochs[len(epochs)-1]
+	}
+	sum.Interval = modalInterval(epochs)
+	interval := sum.HeaderInterval
+	if interval == 0 {
+		interval = sum.Interval
+	}
+	sum.Gaps = findGaps(epochs, interval)
+
+	// systems in the order of the header, followed by the others
+	order := obsTypesOrder(lines)
+	var others []string
+	for sys := range systems {
+		if !slices.Contains(order, sys) {
+			others = append(others, sys)
+		}
+	}
+	sort.Strings(others)
+
+	for _, sys := range append(order, others...) {
+		st, ok := systems[sys]
+		if !ok {
+			continue
+		}
+		ss := SystemSummary{System: sys, Epochs: st.epochs}
+
+		// satellites of the system
+		var ids []string
+		for id := range sats {
+			if id[:1] == sys || (id[:1] == " " && sys == "G") {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+
+		total := make(map[string]*ObsSummary)
+		var codes []string
+		satEpochs := 0
+		for _, id := range ids {
+			sat := sats[id]
+			satEpochs += sat.Epochs
+			for _, code := range sat.codes {
+				o := *sat.obs[code]
+				o.Completeness = float64(o.Count) / float64(sat.Epochs)
+				sat.Obs = append(sat.Obs, o)
+
+				if total[code] == nil {
+					total[code] = &ObsSummary{Code: code}
+					codes = append(codes, code)
+				}
+				total[code].Count += o.Count
+				total[code].LLI += o.LLI
+			}
+			ss.Satellites = append(ss.Satellites, sat.SatSummary)
+		}
+		for _, code := range codes {
+			o := *total[code]
+			o.Completeness = float64(o.Count) / float64(satEpochs)
+			ss.Obs = append(ss.Obs, o)
+		}
+		sum.Systems = append(sum.Systems, ss)
+	}
+
+	return sum, nil
+}
+
+// sysStats accumulates the statistics of a satellite system.
+type sysStats struct {
+	epochs int
+}
+
+// satStats accumulates the statistics of a satellite.
+type satStats struct {
+	SatSummary
+	codes []string // observation codes in the order of appearance
+	obs   map[string]*ObsSummary
+}
+
+// add counts an observation of the code, if ok is true.
+func (st *satStats) add(code string, ok, lli bool) {
+	if st.obs == nil {
+		st.obs = make(map[string]*ObsSummary)
+	}
+	o, found := st.obs[code]
+	if !found {
+		o = &ObsSummary{Code: code}
+		st.obs[code] = o
+		st.codes = append(st.codes, code)
+	}
+	if ok {
+		o.Count++
+	}
+	if lli {
+		o.LLI++
+	}
+}
+
+// modalInterval returns the most frequent positive spacing of the epochs, or
+// 0 if there are less than 2 epochs. The shortest spacing wins a tie.
+func modalInterval(epochs []time.Time) time.Duration {
+	counts := make(map[time.Duration]int)
+	for i := 1; i < len(epochs); i++ {
+		if dt := epochs[i].Sub(epochs[i-1]); dt > 0 {
+			counts[dt]++
+		}
+	}
+
+	var (
+		mode time.Duration
+		most int
+	)
+	for dt, n := range counts {
+		if n > most || (n == most && dt < mode) {
+			mode, most = dt, n
+		}
+	}
+	return mode
+}
+
+// findGaps returns the spacings of the epochs longer than 1.5 times the
+// interval.
+func findGaps(epochs []time.Time, interval time.Duration) (gaps []Gap) {
+	for i := 1; i < len(epochs); i++ {
+		if n := missingEpochs(epochs[i].Sub(epochs[i-1]), interval); n > 0 {
+			gaps = append(gaps, Gap{Start: epochs[i-1], End: epochs[i], Missing: n})
+		}
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package crinex
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	b, err := os.ReadFile("testdata/event_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := Analyze(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(min, sec int) time.Time { return time.Date(2023, 1, 1, 0, min, sec, 0, time.UTC) }
+	if sum.CRINEXVersion != "3.0" || sum.RINEXVersion != "3.04" {
+		t.Errorf("versions = %s %s", sum.CRINEXVersion, sum.RINEXVersion)
+	}
+	if !sum.First.Equal(at(0, 0)) || !sum.Last.Equal(at(1, 30)) || sum.Epochs != 3 {
+		t.Errorf("%v - %v, %d epochs", sum.First, sum.Last, sum.Epochs)
+	}
+	if sum.HeaderInterval != 30*time.Second || sum.Interval != 30*time.Second {
+		t.Errorf("intervals = %v, %v", sum.HeaderInterval, sum.Interval)
+	}
+	if len(sum.Gaps) != 1 || sum.Gaps[0] != (Gap{at(0, 30), at(1, 30), 1}) {
+		t.Errorf("gaps = %+v", sum.Gaps)
+	}
+	if len(sum.EpochFlags) != 2 || sum.EpochFlags[0] != 3 || sum.EpochFlags[4] != 1 {
+		t.Errorf("epoch flags = %v", sum.EpochFlags)
+	}
+	if sum.ClockEpochs != 0 {
+		t.Errorf("clock epochs = %d", sum.ClockEpochs)
+	}
+
+	// G01 and G02 with 4 codes, and R03 with 2 codes, in every epoch
+	if len(sum.Systems) != 2 {
+		t.Fatalf("systems = %+v", sum.Systems)
+	}
+	for i, want := range []struct {
+		sys   string
+		sats  int
+		codes int
+	}{{"G", 2, 4}, {"R", 1, 2}} {
+		sys := sum.Systems[i]
+		if sys.System != want.sys || sys.Epochs != 3 || len(sys.Satellites) != want.sats || len(sys.Obs) != want.codes {
+			t.Errorf("system %+v, want %s with %d satellites and %d codes", sys, want.sys, want.sats, want.codes)
+			continue
+		}
+		for _, o := range sys.Obs {
+			if o.Count != 3*want.sats || o.Completeness != 1 || o.LLI != 0 {
+				t.Errorf("%s %+v", sys.System, o)
+			}
+		}
+	}
+}
+
+func TestAnalyzeClockAndLLI(t *testing.T) {
+	// LLI 1 set to C1 of G01 in the first epoch, and kept in the second
+	b := corrupted(t, "testdata/example_v1.crx", "3&81800000000  5 5 5 5\n", "3&81800000000 15 5 5 5\n")
+	sum, err := Analyze(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sum.ClockEpochs != 2 || sum.ClockMin != 0.000123456 || sum.ClockMax != 0.000123466 {
+		t.Errorf("clock: %d epochs, %g - %g", sum.ClockEpochs, sum.ClockMin, sum.ClockMax)
+	}
+	if sum.LLI != 2 {
+		t.Errorf("LLI = %d, want 2", sum.LLI)
+	}
+	g01 := sum.Systems[0].Satellites[0]
+	if g01.Sat != "G01" || g01.Obs[0].Code != "C1" || g01.Obs[0].LLI != 2 || g01.Obs[1].LLI != 0 {
+		t.Errorf("G01 = %+v", g01)
+	}
+}
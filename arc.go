@@ -0,0 +1,331 @@
+package crinex
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------
+// Carrier phase arcs
+// ---------------------------------------------------
+
+// ArcEnd is the reason why an arc ended.
+type ArcEnd int
+
+const (
+	ArcEndOfData     ArcEnd = iota // end of the data, see ArcTracker.Flush
+	ArcGap                         // phase not observed for longer than MaxGap
+	ArcLostLock                    // LLI bit 0 set at the next observation
+	ArcPowerFailure                // epoch flag 1 at the next epoch
+	ArcReinitialized               // first epoch of a new file in the stream
+)
+
+func (e ArcEnd) String() string {
+	switch e {
+	case ArcEndOfData:
+		return "end-of-data"
+	case ArcGap:
+		return "gap"
+	case ArcLostLock:
+		return "lost-lock"
+	case ArcPowerFailure:
+		return "power-failure"
+	case ArcReinitialized:
+		return "reinitialized"
+	}
+	return fmt.Sprintf("arc-end(%d)", int(e))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e ArcEnd) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// Arc is a continuous carrier phase arc of a satellite and a phase
+// observation code.
+type Arc struct {
+	Sat    string    `json:"sat"`
+	Code   string    `json:"code"`  // phase observation code, e.g. "L1C"
+	Start  time.Time `json:"start"` // first epoch
+	End    time.Time `json:"end"`   // last epoch
+	Epochs int       `json:"epochs"`
+	Reason ArcEnd    `json:"reason"` // reason why the arc ended
+}
+
+// arcKey identifies the arc of a satellite and a phase observation code.
+type arcKey struct {
+	sat  string
+	code string
+}
+
+// ArcTracker follows the carrier phase of every satellite and phase
+// observation code across the epochs of a Scanner, and splits it into
+// continuous arcs. An arc ends when the phase is not observed for longer
+// than MaxGap, at the observation with LLI bit 0 (lost lock) set, at the
+// epoch with the epoch flag 1 (power failure), and at the first epoch of a
+// new file. The initialization epochs of CRINEX only restart the differences,
+// and do not end the arcs.
+type ArcTracker struct {
+	// MaxGap is the longest spacing of the observations within an arc. If
+	// zero, 1.5 times the header INTERVAL is used, or the arc ends at the
+	// first epoch without the phase if INTERVAL is not given.
+	MaxGap time.Duration
+
+	open    map[arcKey]*Arc
+	maxGap  time.Duration // MaxGap or given by the header
+	started bool
+}
+
+// NewArcTracker returns a new ArcTracker.
+func NewArcTracker(maxGap time.Duration) *ArcTracker {
+	return &ArcTracker{MaxGap: maxGap}
+}
+
+// Update follows the arcs to the current epoch of s, and returns the arcs
+// ended before the epoch.
+func (t *ArcTracker) Update(s *Scanner) (ended []Arc) {
+	return t.update(s, s.Data())
+}
+
+// update follows the arcs to the observations obs of the current epoch of s.
+func (t *ArcTracker) update(s *Scanner, obs []SatObss) (ended []Arc) {
+	if t.open == nil {
+		t.open = make(map[arcKey]*Arc)
+	}
+	if !t.started || s.FileBoundary() {
+		t.started = true
+		t.maxGap = maxGapOf(s.Header(), t.MaxGap)
+	}
+
+	epoch := s.Epoch()
+
+	// arcs broken at the epoch as a whole
+	switch {
+	case s.EpochFlag() == 1:
+		ended = t.closeAll(ArcPowerFailure, ended)
+	case s.FileBoundary():
+		ended = t.closeAll(ArcReinitialized, ended)
+	}
+
+	seen := make(map[arcKey]bool)
+	for _, o := range obs {
+		codes := s.ObsTypes()[o.SatId[:1]]
+		for j, d := range o.ObsData {
+			if j >= len(codes) || !strings.HasPrefix(codes[j], "L") || math.IsNaN(d.Data) {
+				continue
+			}
+			key := arcKey{o.SatId, codes[j]}
+			seen[key] = true
+
+			if a, ok := t.open[key]; ok {
+				l, _ := d.LossOfLock()
+				switch {
+				case t.maxGap > 0 && epoch.Sub(a.End) > t.maxGap:
+					ended = t.close(key, ArcGap, ended)
+				case l.LostLock():
+					ended = t.close(key, ArcLostLock, ended)
+				default:
+					a.End = epoch
+					a.Epochs++
+					continue
+				}
+			}
+			t.open[key] = &Arc{Sat: o.SatId, Code: codes[j], Start: epoch, End: epoch, Epochs: 1}
+		}
+	}
+
+	// arcs without the phase at the epoch
+	for key, a := range t.open {
+		if !seen[key] && (t.maxGap == 0 || epoch.Sub(a.End) > t.maxGap) {
+			ended = t.close(key, ArcGap, ended)
+		}
+	}
+
+	sortArcs(ended)
+	return ended
+}
+
+// start returns the first epoch of the open arc of the satellite and the
+// phase observation code, or the zero time if not open.
+func (t *ArcTracker) start(sat, code string) time.Time {
+	if a, ok := t.open[arcKey{sat, code}]; ok {
+		return a.Start
+	}
+	return time.Time{}
+}
+
+// Flush ends all the open arcs with ArcEndOfData, and returns them.
+func (t *ArcTracker) Flush() []Arc {
+	ended := t.closeAll(ArcEndOfData, nil)
+	sortArcs(ended)
+	return ended
+}
+
+// close ends the open arc of key and appends it to ended.
+func (t *ArcTracker) close(key arcKey, reason ArcEnd, ended []Arc) []Arc {
+	a := t.open[key]
+	a.Reason = reason
+	delete(t.open, key)
+	return append(ended, *a)
+}
+
+// closeAll ends all the open arcs and appends them to ended.
+func (t *ArcTracker) closeAll(reason ArcEnd, ended []Arc) []Arc {
+	for key := range t.open {
+		ended = t.close(key, reason, ended)
+	}
+	return ended
+}
+
+// maxGapOf returns maxGap if not zero, otherwise 1.5 times the INTERVAL of
+// the header, or 0 if not given.
+func maxGapOf(header []byte, maxGap time.Duration) time.Duration {
+	if maxGap != 0 {
+		return maxGap
+	}
+	if v, ok := headerInterval(headerLines(header)); ok && v > 0 {
+		return time.Duration(1.5 * v * float64(time.Second))
+	}
+	return 0
+}
+
+// sortArcs sorts the arcs by the satellite, the observation code and the
+// start.
+func sortArcs(arcs []Arc) {
+	sort.Slice(arcs, func(i, j int) bool {
+		a, b := arcs[i], arcs[j]
+		if a.Sat != b.Sat {
+			return a.Sat < b.Sat
+		}
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		return a.Start.Before(b.Start)
+	})
+}
+
+// ---------------------------------------------------
+// Arcs of the combinations of two phases
+// ---------------------------------------------------
+
+// comboKey identifies a combination of the observations of a satellite,
+// e.g. a multipath or a slant TEC combination.
+type comboKey struct {
+	sat    string
+	phases [2]string // phase observations, e.g. ["L1C", "L2W"]
+	codes  [2]string // code observations, the second is empty for one code
+}
+
+// comboArc holds the values of a combination over an arc.
+type comboArc struct {
+	key    comboKey
+	starts [2]time.Time // first epochs of the arcs of the phases
+	epochs []time.Time
+	values [][2]float64
+}
+
+// comboTracker follows the arcs of the combinations of two phase
+// observations on an ArcTracker. The arc of a combination ends when the arc
+// of either phase ends, or when a SlipDetector detects a cycle slip on the
+// pair of the bands of the phases. The slips of the other pairs do not end
+// the arc, e.g. a slip of L1/L5 ends MP5 but not MP1 and MP2 with L1/L2,
+// which detect a slip of L1 on their own.
+type comboTracker struct {
+	minArc int
+	phases *ArcTracker
+	det    *SlipDetector
+	open   map[comboKey]*comboArc
+
+	freq    FreqTable // frequencies of the current file
+	started bool
+}
+
+// newComboTracker returns a new comboTracker. The arcs shorter than minArc
+// epochs are discarded.
+func newComboTracker(maxGap time.Duration, minArc int, slip SlipOptions) *comboTracker {
+	return &comboTracker{
+		minArc: minArc,
+		phases: NewArcTracker(maxGap),
+		det:    NewSlipDetector(slip),
+		open:   make(map[comboKey]*comboArc),
+	}
+}
+
+// update follows the arcs to the current epoch of s, and returns the
+// observations of the epoch and the arcs ended before the epoch.
+func (t *comboTracker) update(s *Scanner) (obs []SatObss, ended []*comboArc) {
+	if !t.started || s.FileBoundary() {
+		t.started = true
+		t.freq = NewFreqTable(s.Header())
+	}
+
+	obs = s.Data()
+	t.phases.update(s, obs)
+	slipped := slippedBands(t.det.update(s, obs))
+
+	for key, a := range t.open {
+		if a.starts != t.starts(key) || slippedPair(slipped[key.sat], key.phases) {
+			ended = t.close(key, ended)
+		}
+	}
+	return obs, ended
+}
+
+// add appends the values v of the combination at the epoch. The phases of
+// the combination must be observed at the current epoch.
+func (t *comboTracker) add(key comboKey, epoch time.Time, v [2]float64) {
+	a, ok := t.open[key]
+	if !ok {
+		a = &comboArc{key: key, starts: t.starts(key)}
+		t.open[key] = a
+	}
+	a.epochs = append(a.epochs, epoch)
+	a.values = append(a.values, v)
+}
+
+// flush ends all the open arcs, and returns them.
+func (t *comboTracker) flush() (ended []*comboArc) {
+	for key := range t.open {
+		ended = t.close(key, ended)
+	}
+	return ended
+}
+
+// close ends the open arc of key, and appends it to ended unless shorter
+// than minArc.
+func (t *comboTracker) close(key comboKey, ended []*comboArc) []*comboArc {
+	a := t.open[key]
+	delete(t.open, key)
+	if len(a.values) < t.minArc {
+		return ended
+	}
+	return append(ended, a)
+}
+
+// starts returns the first epochs of the open arcs of the phases of key.
+func (t *comboTracker) starts(key comboKey) [2]time.Time {
+	return [2]time.Time{t.phases.start(key.sat, key.phases[0]), t.phases.start(key.sat, key.phases[1])}
+}
+
+// slippedBands returns the pairs of the bands of the cycle slips of every
+// satellite.
+func slippedBands(slips []Slip) map[string][][2]byte {
+	bands := make(map[string][][2]byte)
+	for _, slip := range slips {
+		bands[slip.Sat] = append(bands[slip.Sat], [2]byte{slip.Codes[0][1], slip.Codes[1][1]})
+	}
+	return bands
+}
+
+// slippedPair reports whether a cycle slip of the pair of the bands of the
+// phases is in slipped.
+func slippedPair(slipped [][2]byte, phases [2]string) bool {
+	i, j := phases[0][1], phases[1][1]
+	return slices.ContainsFunc(slipped, func(b [2]byte) bool {
+		return b == [2]byte{i, j} || b == [2]byte{j, i}
+	})
+}
@@ -0,0 +1,75 @@
+package crinex
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// trackArcs returns all the arcs of the CRINEX file b.
+func trackArcs(t *testing.T, b []byte) (arcs []Arc) {
+	t.Helper()
+	s, err := NewScanner(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := NewArcTracker(0)
+	for s.ScanEpoch() {
+		arcs = append(arcs, tr.Update(s)...)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return append(arcs, tr.Flush()...)
+}
+
+func TestArcTracker(t *testing.T) {
+	at := func(min, sec int) time.Time { return time.Date(2023, 1, 1, 0, min, sec, 0, time.UTC) }
+
+	// event_v3.crx without the event, and the initialization epoch at 00:01:00
+	header := firstLines(t, "testdata/event_v3.crx", 9)
+	file := firstLines(t, "testdata/event_v3.crx", 26)
+	file = append(file[:bytes.Index(file, []byte("> 2023 01 01 00 01  0.0000000  4"))],
+		bytes.ReplaceAll(file[bytes.Index(file, []byte("> 2023 01 01 00 01 30")):], []byte("00 01 30"), []byte("00 01  0"))...)
+	lastEpoch := file[bytes.Index(file, []byte("> 2023 01 01 00 01  0")):]
+
+	// the last epoch in a new file 30 seconds later
+	next := append(bytes.Clone(header), bytes.ReplaceAll(lastEpoch, []byte("00 01  0"), []byte("00 01 30"))...)
+
+	tests := []struct {
+		name string
+		b    []byte
+		want []Arc // arcs of G01 L1C
+	}{
+		{"initialization", file, []Arc{
+			{"G01", "L1C", at(0, 0), at(1, 0), 3, ArcEndOfData},
+		}},
+		{"new-file", append(bytes.Clone(file), next...), []Arc{
+			{"G01", "L1C", at(0, 0), at(1, 0), 3, ArcReinitialized},
+			{"G01", "L1C", at(1, 30), at(1, 30), 1, ArcEndOfData},
+		}},
+		{"gap", firstLines(t, "testdata/example_v3.crx", 24), []Arc{
+			{"G01", "L1C", at(0, 0), at(0, 30), 2, ArcGap},
+			{"G01", "L1C", at(1, 30), at(1, 30), 1, ArcEndOfData},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Arc
+			for _, a := range trackArcs(t, tt.b) {
+				if a.Sat == "G01" && a.Code == "L1C" {
+					got = append(got, a)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("arcs = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("arc %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
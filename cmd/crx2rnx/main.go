@@ -0,0 +1,236 @@
+// Command crx2rnx converts Hatanaka RINEX (CRINEX) files to RINEX files.
+//
+// Usage:
+//
+//	crx2rnx [-j N] [-d outdir] [-f] [-v] file|dir ...
+//	crx2rnx - < input.crx > output.rnx
+//
+// Directories are walked recursively and every CRINEX file found
+// ("*.crx", "*.YYd", optionally gzipped) is converted. The output file is
+// written next to the input file, or below outdir keeping the relative path
+// when -d is given. Files whose output file exists are reported as failed and
+// skipped, unless -f is given. With -j N the files are converted by N workers.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/satoshi-pes/crinex"
+)
+
+func main() {
+	var (
+		workers = flag.Int("j", 1, "number of files converted in parallel")
+		outDir  = flag.String("d", "", "output directory (default: next to the input file)")
+		force   = flag.Bool("f", false, "overwrite existing output files")
+		verbose = flag.Bool("v", false, "print warnings and a line for every converted file")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: crx2rnx [-j N] [-d outdir] [-f] [-v] file|dir ...\n")
+		fmt.Fprintf(os.Stderr, "       crx2rnx - < input.crx > output.rnx\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// stdin to stdout
+	if flag.NArg() == 1 && flag.Arg(0) == "-" {
+		_, warns, err := crinex.Convert(os.Stdout, os.Stdin)
+		if *verbose {
+			for _, w := range warns {
+				fmt.Fprintf(os.Stderr, "crx2rnx: warning: %s\n", w)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "crx2rnx: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var (
+		start            = time.Now()
+		numOK, numFailed int
+		numEpochs        int
+		numSkipped       int // counted by the walker, read after walkErrs
+	)
+
+	jobs := make(chan crinex.Job)
+	walkErrs := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		walkErrs <- collectJobs(ctx, jobs, flag.Args(), *outDir, *force, func(err error) {
+			numSkipped++
+			fmt.Fprintf(os.Stderr, "crx2rnx: %v\n", err)
+		})
+	}()
+
+	for res := range crinex.ConvertAll(ctx, jobs, *workers) {
+		if res.Err != nil {
+			numFailed++
+			fmt.Fprintf(os.Stderr, "crx2rnx: %v\n", res.Err)
+			continue
+		}
+		numOK++
+		numEpochs += res.Epochs
+
+		if *verbose {
+			fmt.Fprintf(os.Stderr, "%s -> %s: %d epochs, %d warnings, %v\n",
+				res.Job.Input, res.Output, res.Epochs, res.Warnings.Len(), res.Elapsed.Round(time.Millisecond))
+			for _, w := range res.Warnings {
+				fmt.Fprintf(os.Stderr, "  warning: %s\n", w)
+			}
+		}
+	}
+
+	if err := <-walkErrs; err != nil && ctx.Err() == nil {
+		numFailed++
+		fmt.Fprintf(os.Stderr, "crx2rnx: %v\n", err)
+	}
+	numFailed += numSkipped
+	if err := ctx.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "crx2rnx: interrupted\n")
+		os.Exit(1)
+	}
+
+	if *verbose || numOK+numFailed > 1 {
+		fmt.Fprintf(os.Stderr, "crx2rnx: %d converted (%d epochs), %d failed, %v\n",
+			numOK, numEpochs, numFailed, time.Since(start).Round(time.Millisecond))
+	}
+	if numFailed > 0 {
+		os.Exit(1)
+	}
+}
+
+// collectJobs walks the paths and sends a job for every CRINEX file found.
+// The files that cannot be converted, e.g. because the output file exists,
+// are passed to skip and the walk goes on. The walk stops on ctx.
+func collectJobs(ctx context.Context, jobs chan<- crinex.Job, paths []string, outDir string, force bool, skip func(error)) error {
+	send := func(in, rel string) error {
+		out, ok := outputName(rel)
+		if !ok {
+			skip(fmt.Errorf("%s: not a CRINEX file name", in))
+			return nil
+		}
+		if outDir != "" {
+			out = filepath.Join(outDir, out)
+			if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+				skip(fmt.Errorf("%s: %w", in, err))
+				return nil
+			}
+		}
+		if !force {
+			if _, err := os.Stat(out); err == nil {
+				skip(fmt.Errorf("%s: output file %s exists (use -f to overwrite)", in, out))
+				return nil
+			}
+		}
+
+		select {
+		case jobs <- crinex.Job{Input: in, Output: out}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			skip(err)
+			continue
+		}
+
+		if !info.IsDir() {
+			rel := p
+			if outDir != "" {
+				rel = filepath.Base(p)
+			}
+			if err := send(p, rel); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// unreadable directory or file
+				skip(err)
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if _, ok := outputName(d.Name()); !ok {
+				// not a CRINEX file
+				return nil
+			}
+
+			rel := path
+			if outDir != "" {
+				if rel, err = filepath.Rel(p, path); err != nil {
+					skip(err)
+					return nil
+				}
+			}
+			return send(path, rel)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// outputName returns the RINEX file name for the CRINEX file name, e.g.
+// "abcd0010.23d.gz" -> "abcd0010.23o" and
+// "ABCD00XXX_R_20230010000_01D_30S_MO.crx.gz" -> "ABCD00XXX_R_20230010000_01D_30S_MO.rnx".
+// Returns false if name is not a CRINEX file name.
+func outputName(name string) (string, bool) {
+	dir, base := filepath.Split(name)
+
+	// RINEX file names, e.g. "abcd0010.23d.gz"
+	if f, err := crinex.ParseFileName(base); err == nil {
+		if f.Format != "crx" || (f.Compress != "" && f.Compress != "gz") {
+			return "", false
+		}
+		f.Format, f.Compress = "rnx", ""
+
+		out := f.String()
+		if !f.Long && base == strings.ToUpper(base) {
+			// keep upper case short names, e.g. "ABCD0010.23D"
+			out = strings.ToUpper(out)
+		}
+		return dir + out, true
+	}
+
+	// other names ending with ".crx"
+	name = strings.TrimSuffix(name, ".gz")
+	ext := filepath.Ext(name)
+	base = strings.TrimSuffix(name, ext)
+
+	switch ext {
+	case ".crx":
+		return base + ".rnx", true
+	case ".CRX":
+		return base + ".RNX", true
+	}
+
+	return "", false
+}
@@ -0,0 +1,213 @@
+// Command crxinfo prints the observation statistics of Hatanaka RINEX
+// (CRINEX) files.
+//
+// Usage:
+//
+//	crxinfo [-json] [-sat] [-mp] file ...
+//
+// For every file the time span, the interval, the gaps, the epoch flags, the
+// receiver clock offset range, the LLI counts and the completeness of every
+// observation code by satellite system are printed. -sat adds the statistics
+// of every satellite, and -mp the RMS of the code multipath (MP1, MP2, ...).
+// Gzipped files ("*.gz") are decompressed on the fly.
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/satoshi-pes/crinex"
+)
+
+type fileSummary struct {
+	File string `json:"file"`
+	crinex.Summary
+	Multipath *crinex.MultipathResult `json:"multipath,omitempty"`
+}
+
+func main() {
+	var (
+		asJSON = flag.Bool("json", false, "output as JSON")
+		bySat  = flag.Bool("sat", false, "print the statistics of every satellite")
+		mp     = flag.Bool("mp", false, "compute the code multipath")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: crxinfo [-json] [-sat] [-mp] file ...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		sums   = []fileSummary{}
+		failed bool
+	)
+	for _, name := range flag.Args() {
+		fs := fileSummary{File: name}
+		err := readFile(name, func(r io.Reader) (err error) {
+			fs.Summary, err = crinex.Analyze(r)
+			return err
+		})
+		if err == nil && *mp {
+			err = readFile(name, func(r io.Reader) error {
+				res, err := crinex.Multipath(r, crinex.MultipathOptions{})
+				fs.Multipath = &res
+				return err
+			})
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "crxinfo: %s: %v\n", name, err)
+			failed = true
+			continue
+		}
+
+		if *asJSON {
+			sums = append(sums, fs)
+			continue
+		}
+		printSummary(os.Stdout, fs, *bySat)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(sums); err != nil {
+			fmt.Fprintf(os.Stderr, "crxinfo: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// readFile opens the file and calls read with the decompressed contents.
+func readFile(name string, read func(io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return read(r)
+}
+
+func printSummary(w io.Writer, fs fileSummary, bySat bool) {
+	const layout = time.DateTime
+	sum := fs.Summary
+
+	fmt.Fprintf(w, "%s\n", fs.File)
+	fmt.Fprintf(w, "  version:      CRINEX %s, RINEX %s\n", sum.CRINEXVersion, sum.RINEXVersion)
+	fmt.Fprintf(w, "  epochs:       %d, %s - %s\n", sum.Epochs, sum.First.Format(layout), sum.Last.Format(layout))
+
+	header := "not given"
+	if sum.HeaderInterval > 0 {
+		header = sum.HeaderInterval.String()
+	}
+	fmt.Fprintf(w, "  interval:     %v (header %s)\n", sum.Interval, header)
+
+	fmt.Fprintf(w, "  gaps:         %d\n", len(sum.Gaps))
+	for _, g := range sum.Gaps {
+		fmt.Fprintf(w, "    %s - %s, %d epochs missing\n", g.Start.Format(layout), g.End.Format(layout), g.Missing)
+	}
+
+	var flags []int
+	for f := range sum.EpochFlags {
+		flags = append(flags, f)
+	}
+	sort.Ints(flags)
+	var hist []string
+	for _, f := range flags {
+		hist = append(hist, fmt.Sprintf("%d:%d", f, sum.EpochFlags[f]))
+	}
+	fmt.Fprintf(w, "  epoch flags:  %s\n", strings.Join(hist, " "))
+
+	if sum.ClockEpochs > 0 {
+		fmt.Fprintf(w, "  clock offset: %.12f - %.12f s (%d epochs)\n", sum.ClockMin, sum.ClockMax, sum.ClockEpochs)
+	} else {
+		fmt.Fprintf(w, "  clock offset: not given\n")
+	}
+	fmt.Fprintf(w, "  LLI:          %d\n", sum.LLI)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', tabwriter.AlignRight)
+	for _, ss := range sum.Systems {
+		fmt.Fprintf(w, "  system %s: %d satellites, %d epochs\n", ss.System, len(ss.Satellites), ss.Epochs)
+		printObs(tw, "", 0, ss.Obs)
+		if bySat {
+			for _, sat := range ss.Satellites {
+				printObs(tw, sat.Sat, sat.Epochs, sat.Obs)
+			}
+		}
+		tw.Flush()
+	}
+
+	if fs.Multipath != nil {
+		printMultipath(w, fs.Multipath, bySat)
+	}
+	fmt.Fprintln(w)
+}
+
+// printMultipath prints the RMS of the multipath of every signal, and of
+// every satellite if bySat is true.
+func printMultipath(w io.Writer, res *crinex.MultipathResult, bySat bool) {
+	fmt.Fprintf(w, "  multipath RMS:\n")
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	for _, sig := range res.Signals {
+		fmt.Fprintf(tw, "    %s\t%s\t%s\t%.3f m\t%d satellites, %d epochs\n",
+			sig.System, sig.Name, sig.Code, sig.RMS, sig.Sats, sig.Epochs)
+	}
+	if bySat {
+		for _, sat := range res.Sats {
+			fmt.Fprintf(tw, "    %s\t%s\t%s\t%.3f m\t%d arcs, %d epochs\n",
+				sat.Sat, sat.Name, sat.Code, sat.RMS, sat.Arcs, sat.Epochs)
+		}
+	}
+	tw.Flush()
+}
+
+// printObs prints a row of the completeness and the LLI count of every
+// observation code.
+func printObs(w io.Writer, label string, epochs int, obs []crinex.ObsSummary) {
+	if label == "" {
+		// header row of the codes
+		fmt.Fprintf(w, "    \t")
+		for _, o := range obs {
+			fmt.Fprintf(w, "%s\t", o.Code)
+		}
+		fmt.Fprintf(w, "\n    all\t")
+	} else {
+		fmt.Fprintf(w, "    %s %d\t", label, epochs)
+	}
+
+	for _, o := range obs {
+		if o.LLI > 0 {
+			fmt.Fprintf(w, " %.1f%% (%d)\t", 100*o.Completeness, o.LLI)
+			continue
+		}
+		fmt.Fprintf(w, " %.1f%%\t", 100*o.Completeness)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,177 @@
+// Command crxlint validates Hatanaka RINEX (CRINEX) files and reports every
+// issue with its line number.
+//
+// Usage:
+//
+//	crxlint [-json] [-sampling] file ...
+//
+// Issues are printed as "file:line: severity: code: message", or as a JSON
+// array with -json. -sampling also reports missing epochs and epochs off the
+// sampling grid. The file name is cross-checked against the header if it
+// follows the RINEX file name convention. Gzipped files ("*.gz") are
+// decompressed on the fly.
+// The exit status is 1 if any warning or error is found, and 2 if a file
+// cannot be read.
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/satoshi-pes/crinex"
+)
+
+// codeDecodeError is the code of the issue that stopped the decoding.
+const codeDecodeError crinex.Code = "decode-error"
+
+type issue struct {
+	File     string          `json:"file"`
+	Line     int             `json:"line"`
+	Severity crinex.Severity `json:"severity"`
+	Code     crinex.Code     `json:"code"`
+	Sat      string          `json:"sat,omitempty"`
+	Epoch    string          `json:"epoch,omitempty"`
+	Msg      string          `json:"msg"`
+	Text     string          `json:"text,omitempty"`
+}
+
+func (i issue) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s: %s", i.File, i.Line, i.Severity, i.Code, i.Msg)
+}
+
+func main() {
+	var (
+		asJSON   = flag.Bool("json", false, "output issues as JSON")
+		sampling = flag.Bool("sampling", false, "report missing and off-grid epochs")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: crxlint [-json] [-sampling] file ...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		issues   = []issue{}
+		failed   bool
+		readErrs bool
+	)
+	for _, name := range flag.Args() {
+		found, err := lintFile(name, *sampling)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "crxlint: %v\n", err)
+			readErrs = true
+			continue
+		}
+
+		for _, i := range found {
+			if i.Severity >= crinex.SeverityWarning {
+				failed = true
+			}
+			if !*asJSON {
+				fmt.Println(i)
+			}
+		}
+		issues = append(issues, found...)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(issues); err != nil {
+			fmt.Fprintf(os.Stderr, "crxlint: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	switch {
+	case readErrs:
+		os.Exit(2)
+	case failed:
+		os.Exit(1)
+	}
+}
+
+// lintFile decodes the file and returns the issues found.
+// The returned error is non-nil only if the file cannot be read.
+func lintFile(name string, sampling bool) (issues []issue, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	opts := crinex.Options{
+		OnDiagnostic: func(d crinex.Diagnostic) {
+			issues = append(issues, newIssue(name, d))
+		},
+		CheckSampling: sampling,
+	}
+
+	s, err := crinex.NewScannerWithOptions(r, opts)
+	if err == nil {
+		err = s.ParseHeader()
+	}
+	if err == nil {
+		// cross-check the file name against the header, following the
+		// 2 lines of the CRINEX header
+		if fn, e := crinex.ParseFileName(filepath.Base(name)); e == nil {
+			for _, d := range fn.Check(s.Header()) {
+				d.Pos += 2
+				issues = append(issues, newIssue(name, d))
+			}
+		}
+
+		for s.ScanEpoch() {
+		}
+		err = s.Err()
+	}
+	if err != nil {
+		issues = append(issues, newIssue(name, crinex.Diagnostic{
+			Code:     codeDecodeError,
+			Severity: crinex.SeverityError,
+			Pos:      s.LineNumber(),
+			Msg:      err.Error(),
+		}))
+	}
+
+	return issues, nil
+}
+
+func newIssue(name string, d crinex.Diagnostic) issue {
+	i := issue{
+		File:     name,
+		Line:     d.Pos,
+		Severity: d.Severity,
+		Code:     d.Code,
+		Sat:      d.Sat,
+		Msg:      d.Msg,
+		Text:     d.Line,
+	}
+	if !d.Epoch.IsZero() {
+		i.Epoch = d.Epoch.Format(time.RFC3339Nano)
+	}
+	return i
+}
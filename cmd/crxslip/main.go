@@ -0,0 +1,98 @@
+// Command crxslip detects cycle slips in a Hatanaka RINEX (CRINEX) file with
+// the Melbourne-Wübbena and geometry-free combinations.
+//
+// Usage:
+//
+//	crxslip [-o output] [-event] [-mw cycles] [-gf meters] file
+//
+// The slips detected are printed as "sat codes epoch line mw gf cycles".
+// With -o, the data are also written to the output file in RINEX, with LLI
+// bit 0 set to the phase observations of the slips, or with event flag 6
+// (cycle slip) records with -event. Gzipped files ("*.gz") are decompressed
+// on the fly.
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/satoshi-pes/crinex"
+)
+
+func main() {
+	var (
+		output = flag.String("o", "", "write the RINEX data with the slips marked to the output file")
+		event  = flag.Bool("event", false, "mark the slips with event flag 6 records instead of LLI")
+		mw     = flag.Float64("mw", crinex.DefaultSlipThresholds.MW, "threshold of Melbourne-Wübbena in wide-lane cycles")
+		gf     = flag.Float64("gf", crinex.DefaultSlipThresholds.GF, "threshold of geometry-free in meters")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: crxslip [-o output] [-event] [-mw cycles] [-gf meters] file\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := crinex.SlipOptions{}
+	opts.Thresholds = make(map[string]crinex.SlipThresholds)
+	for _, sys := range crinex.VALID_SATSYS {
+		opts.Thresholds[sys] = crinex.SlipThresholds{MW: *mw, GF: *gf}
+	}
+	if *event {
+		opts.Mark = crinex.SlipMarkEvent
+	}
+
+	slips, err := detect(*output, flag.Arg(0), opts)
+	for _, s := range slips {
+		fmt.Printf("%s %s %s %d %.2f %.3f %v\n", s.Sat, strings.Join(s.Codes[:], ","),
+			s.Epoch.Format(time.RFC3339Nano), s.Line, s.MW, s.GF, s.Cycles)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "crxslip: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func detect(output, name string, opts crinex.SlipOptions) (slips []crinex.Slip, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	if output == "" {
+		return crinex.MarkSlips(io.Discard, r, opts)
+	}
+
+	out, err := os.Create(output)
+	if err != nil {
+		return nil, err
+	}
+	slips, err = crinex.MarkSlips(out, r, opts)
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(output)
+	}
+	return slips, err
+}
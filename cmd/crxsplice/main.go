@@ -0,0 +1,93 @@
+// Command crxsplice merges consecutive Hatanaka RINEX (CRINEX) files of one
+// station into a single RINEX file.
+//
+// Usage:
+//
+//	crxsplice [-o output] [-crx] file ...
+//
+// Files are merged in the order given, and epochs overlapping with the
+// previous file are dropped. The output is written to stdout unless -o is
+// given, in CRINEX with -crx. Gzipped files ("*.gz") are decompressed on the
+// fly.
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/satoshi-pes/crinex"
+)
+
+func main() {
+	var (
+		output = flag.String("o", "", "output file (default: stdout)")
+		crx    = flag.Bool("crx", false, "write CRINEX instead of RINEX")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: crxsplice [-o output] [-crx] file ...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := crinex.SpliceOptions{CRINEX: *crx, Open: open}
+	if err := splice(*output, flag.Args(), opts); err != nil {
+		fmt.Fprintf(os.Stderr, "crxsplice: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func splice(output string, names []string, opts crinex.SpliceOptions) (err error) {
+	if output == "" {
+		return crinex.Splice(os.Stdout, names, opts)
+	}
+
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	err = crinex.Splice(out, names, opts)
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(output)
+	}
+	return err
+}
+
+// open opens the file, decompressing gzipped files.
+func open(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil || !strings.HasSuffix(name, ".gz") {
+		return f, err
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return gzipFile{gz, f}, nil
+}
+
+// gzipFile closes the gzip reader and the file.
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g gzipFile) Close() error {
+	err := g.Reader.Close()
+	if e := g.f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+// Command crxsplit splits a Hatanaka RINEX (CRINEX) file into hourly or
+// N-minute files named in the RINEX 3 long name convention.
+//
+// Usage:
+//
+//	crxsplit [-p period] [-d outdir] [-rnx] [-z] [-country CCC] file
+//
+// By default the pieces are written as CRINEX files, each of which begins
+// with an initialization epoch. Gzipped files ("*.gz") are decompressed on
+// the fly, and -z gzips the output files.
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/satoshi-pes/crinex"
+)
+
+func main() {
+	var (
+		period  = flag.Duration("p", time.Hour, "length of the pieces, e.g. 1h or 15m")
+		outDir  = flag.String("d", ".", "output directory")
+		rnx     = flag.Bool("rnx", false, "write RINEX instead of CRINEX")
+		gz      = flag.Bool("z", false, "gzip the output files")
+		country = flag.String("country", "", "ISO country code for the file names (default XXX)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: crxsplit [-p period] [-d outdir] [-rnx] [-z] [-country CCC] file\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := crinex.SplitOptions{
+		Period:  *period,
+		CRINEX:  !*rnx,
+		Country: *country,
+		Create: func(name string) (io.WriteCloser, error) {
+			return create(filepath.Join(*outDir, name), *gz)
+		},
+	}
+
+	pieces, err := split(flag.Arg(0), opts)
+	for _, p := range pieces {
+		fmt.Printf("%s: %d epochs %s - %s\n", p.Name, p.Epochs,
+			p.First.Format(time.DateTime), p.Last.Format(time.DateTime))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "crxsplit: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func split(name string, opts crinex.SplitOptions) ([]crinex.Piece, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return crinex.Split(r, opts)
+}
+
+// gzipFile closes both the gzip writer and the file.
+type gzipFile struct {
+	*gzip.Writer
+	f *os.File
+}
+
+func (g gzipFile) Close() error {
+	err := g.Writer.Close()
+	if e := g.f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// create creates the output file, gzipped if gz is set.
+func create(name string, gz bool) (io.WriteCloser, error) {
+	if gz {
+		name += ".gz"
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if !gz {
+		return f, nil
+	}
+	return gzipFile{gzip.NewWriter(f), f}, nil
+}
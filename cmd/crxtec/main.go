@@ -0,0 +1,106 @@
+// Command crxtec computes the leveled, uncalibrated slant TEC of every
+// satellite from a Hatanaka RINEX (CRINEX) file.
+//
+// Usage:
+//
+//	crxtec [-json] [-o output] [-minarc epochs] file
+//
+// The slant TEC in TECU is written in CSV with the columns "sat, phases,
+// codes, arc, epoch, stec, phase, code", or in JSON per arc with -json, with
+// the epochs in UTC, to the standard output or to the output file with -o. Gzipped files ("*.gz")
+// are decompressed on the fly.
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/satoshi-pes/crinex"
+)
+
+func main() {
+	var (
+		jsonOut = flag.Bool("json", false, "write the arcs in JSON instead of CSV")
+		output  = flag.String("o", "", "write to the output file instead of the standard output")
+		minArc  = flag.Int("minarc", 0, "minimum number of epochs of an arc (default 10)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: crxtec [-json] [-o output] [-minarc epochs] file\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	arcs, err := slantTEC(flag.Arg(0), crinex.TECOptions{MinArc: *minArc})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "crxtec: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := write(*output, arcs, *jsonOut); err != nil {
+		fmt.Fprintf(os.Stderr, "crxtec: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func slantTEC(name string, opts crinex.TECOptions) ([]crinex.TECArc, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	arcs, err := crinex.SlantTEC(r, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return arcs, nil
+}
+
+func write(output string, arcs []crinex.TECArc, jsonOut bool) (err error) {
+	var w io.Writer = os.Stdout
+	if output != "" {
+		out, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if e := out.Close(); err == nil {
+				err = e
+			}
+			if err != nil {
+				os.Remove(output)
+			}
+		}()
+		w = out
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if arcs == nil {
+			arcs = []crinex.TECArc{}
+		}
+		return enc.Encode(arcs)
+	}
+	return crinex.WriteTECCSV(w, arcs)
+}
@@ -0,0 +1,65 @@
+package crinex
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+// convertBytes returns the RINEX of the CRINEX file b.
+func convertBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if _, _, err := Convert(&out, bytes.NewReader(b)); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestConcatenated(t *testing.T) {
+	v3, err := os.ReadFile("testdata/example_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := os.ReadFile("testdata/example_v1.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the files of different versions one after another
+	b := append(bytes.Clone(v3), v1...)
+	want := append(convertBytes(t, v3), convertBytes(t, v1)...)
+
+	s, err := NewScanner(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var boundaries []int
+	for i := 0; s.ScanEpoch(); i++ {
+		if s.FileBoundary() {
+			boundaries = append(boundaries, i)
+			if got := s.ObsTypes()["G"]; len(got) != 4 || got[0] != "C1" {
+				t.Errorf("obstypes = %v", got)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(boundaries) != 1 || boundaries[0] != 3 {
+		t.Errorf("file boundaries at epochs %v, want [3]", boundaries)
+	}
+
+	if got := convertBytes(t, b); !bytes.Equal(got, want) {
+		t.Errorf("Convert:\n%s\nwant:\n%s", got, want)
+	}
+
+	r, err := NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(r); !bytes.Equal(got, want) {
+		t.Errorf("Reader:\n%s\nwant:\n%s", got, want)
+	}
+}
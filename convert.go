@@ -0,0 +1,195 @@
+package crinex
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------
+// Conversion from Hatanaka RINEX to RINEX
+// ---------------------------------------------------
+
+// Job describes a conversion of a Hatanaka RINEX file to a RINEX file.
+// Input files with the suffix ".gz" are decompressed on the fly.
+type Job struct {
+	Input  string // path to the Hatanaka RINEX file
+	Output string // path to the RINEX file to be written
+}
+
+// Result reports the outcome of a Job.
+type Result struct {
+	Job      Job
+	Output   string        // path to the written RINEX file
+	Epochs   int           // number of converted epochs
+	Warnings WarningList   // warnings raised while decoding
+	Err      error         // non-nil if the conversion failed
+	Elapsed  time.Duration // time spent on the conversion
+}
+
+// Convert decodes Hatanaka RINEX data read from r and writes it to w in the
+// RINEX format. Special events are written before the epochs they precede,
+// and the files concatenated in r are written one after another with their
+// headers. It returns the number of converted epochs and the warnings raised by the
+// scanner.
+func Convert(w io.Writer, r io.Reader) (epochs int, warns WarningList, err error) {
+	return convert(context.Background(), w, r)
+}
+
+// convert is the context aware implementation of Convert.
+// The context is checked every epoch.
+func convert(ctx context.Context, w io.Writer, r io.Reader) (epochs int, warns WarningList, err error) {
+	s, err := NewScanner(r)
+	if err != nil {
+		return 0, s.Warnings, err
+	}
+
+	if err = s.ParseHeader(); err != nil {
+		return 0, s.Warnings, err
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err = bw.Write(s.Header()); err != nil {
+		return 0, s.Warnings, err
+	}
+
+	for s.ScanEpoch() {
+		if err = ctx.Err(); err != nil {
+			return epochs, s.Warnings, err
+		}
+
+		if s.FileBoundary() {
+			// header of the next file in the concatenated stream
+			if _, err = bw.Write(s.Header()); err != nil {
+				return epochs, s.Warnings, err
+			}
+		}
+		if _, err = bw.Write(s.EventsAsBytes()); err != nil {
+			return epochs, s.Warnings, err
+		}
+		if _, err = bw.Write(s.EpochAsBytes()); err != nil {
+			return epochs, s.Warnings, err
+		}
+		if _, err = bw.Write(s.DataAsBytes()); err != nil {
+			return epochs, s.Warnings, err
+		}
+		epochs++
+	}
+	if err = s.Err(); err != nil {
+		bw.Flush()
+		return epochs, s.Warnings, err
+	}
+
+	// special events at the end of the file
+	if _, err = bw.Write(s.EventsAsBytes()); err != nil {
+		return epochs, s.Warnings, err
+	}
+
+	return epochs, s.Warnings, bw.Flush()
+}
+
+// ConvertFile converts job.Input to job.Output. The output file is removed if
+// the conversion fails, except for truncated input files where the epochs
+// decoded are kept and Result.Err wraps ErrTruncated.
+func ConvertFile(ctx context.Context, job Job) (res Result) {
+	start := time.Now()
+	res.Job = job
+	defer func() { res.Elapsed = time.Since(start) }()
+
+	f, err := os.Open(job.Input)
+	if err != nil {
+		res.Err = err
+		return
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(job.Input, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			res.Err = fmt.Errorf("%s: %w", job.Input, err)
+			return
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	out, err := os.Create(job.Output)
+	if err != nil {
+		res.Err = err
+		return
+	}
+
+	res.Epochs, res.Warnings, err = convert(ctx, out, r)
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if errors.Is(err, ErrTruncated) {
+		res.Output = job.Output
+		res.Err = fmt.Errorf("%s: %w", job.Input, err)
+		return
+	}
+	if err != nil {
+		os.Remove(job.Output)
+		res.Err = fmt.Errorf("%s: %w", job.Input, err)
+		return
+	}
+	res.Output = job.Output
+
+	return
+}
+
+// ConvertAll converts the jobs received from jobs with a pool of workers
+// goroutines, and sends a Result for every job to the returned channel.
+// The returned channel is closed after jobs is closed and all the received
+// jobs are finished, or after ctx is cancelled. Results are not ordered.
+func ConvertAll(ctx context.Context, jobs <-chan Job, workers int) <-chan Result {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var (
+					job Job
+					ok  bool
+				)
+				select {
+				case <-ctx.Done():
+					return
+				case job, ok = <-jobs:
+					if !ok {
+						return
+					}
+				}
+
+				res := ConvertFile(ctx, job)
+				select {
+				case <-ctx.Done():
+					return
+				case results <- res:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
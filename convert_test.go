@@ -0,0 +1,144 @@
+package crinex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readerOutput returns the RINEX decoded by NewReader from the file.
+func readerOutput(t *testing.T, name string) []byte {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := NewReader(f)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		epochs int
+		events []string // lines of the special events in the output
+	}{
+		{"testdata/example_v1.crx", 2, nil},
+		{"testdata/example_v3.crx", 3, nil},
+		{"testdata/picosec_v31.crx", 3, nil},
+		{"testdata/event_v1.crx", 3, []string{
+			" 99  6 12  0 15  0.0000000  4  1\n" +
+				"RECEIVER RESTARTED                                          COMMENT\n",
+		}},
+		{"testdata/event_v3.crx", 3, []string{
+			"> 2023 01 01 00 01  0.0000000  4  1\n" +
+				"RECEIVER RESTARTED                                          COMMENT\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.name), func(t *testing.T) {
+			f, err := os.Open(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var out bytes.Buffer
+			epochs, _, err := Convert(&out, f)
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			if epochs != tt.epochs {
+				t.Errorf("epochs = %d, want %d", epochs, tt.epochs)
+			}
+			for _, ev := range tt.events {
+				if !strings.Contains(out.String(), ev) {
+					t.Errorf("event %q not found in the output", ev)
+				}
+			}
+
+			// the same output as NewReader
+			if want := readerOutput(t, tt.name); !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("Convert and NewReader differ:\n%s\nwant:\n%s", out.Bytes(), want)
+			}
+		})
+	}
+}
+
+// errWriter fails every write.
+type errWriter struct{}
+
+var errWrite = errors.New("write failed")
+
+func (errWriter) Write([]byte) (int, error) { return 0, errWrite }
+
+func TestConvertWriteError(t *testing.T) {
+	f, err := os.Open("testdata/event_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, _, err := Convert(errWriter{}, f); !errors.Is(err, errWrite) {
+		t.Errorf("err = %v, want %v", err, errWrite)
+	}
+}
+
+func TestConvertAll(t *testing.T) {
+	dir := t.TempDir()
+	inputs := []string{"testdata/example_v1.crx", "testdata/example_v3.crx", "testdata/event_v3.crx", "testdata/missing.crx"}
+
+	jobs := make(chan Job)
+	go func() {
+		for _, in := range inputs {
+			jobs <- Job{Input: in, Output: filepath.Join(dir, filepath.Base(in)+".rnx")}
+		}
+		close(jobs)
+	}()
+
+	results := make(map[string]Result)
+	for res := range ConvertAll(context.Background(), jobs, 2) {
+		results[res.Job.Input] = res
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("got %d results, want %d", len(results), len(inputs))
+	}
+
+	for _, in := range inputs[:3] {
+		res := results[in]
+		if res.Err != nil {
+			t.Errorf("%s: %v", in, res.Err)
+			continue
+		}
+		got, err := os.ReadFile(res.Output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := readerOutput(t, in); !bytes.Equal(got, want) {
+			t.Errorf("%s: output differs from NewReader", in)
+		}
+	}
+
+	res := results["testdata/missing.crx"]
+	if !errors.Is(res.Err, os.ErrNotExist) {
+		t.Errorf("missing input: err = %v, want %v", res.Err, os.ErrNotExist)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.crx.rnx")); !os.IsNotExist(err) {
+		t.Errorf("output of the missing input exists")
+	}
+}
@@ -0,0 +1,304 @@
+package crinex
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------
+// Cutting at initialization epochs
+// ---------------------------------------------------
+
+// ErrNoCutPoint is returned by CutAtInit if no initialization epoch is found
+// in the time range.
+var ErrNoCutPoint = errors.New("crinex: No initialization epoch found in the range")
+
+// Cut describes the part of the file copied by CutAtInit.
+type Cut struct {
+	First  time.Time // first epoch, at an initialization epoch
+	Last   time.Time // last epoch
+	Epochs int       // number of epochs
+
+	// lines of the input file copied, [StartLine, EndLine]
+	StartLine int
+	EndLine   int
+}
+
+// CutAtInit copies the part of the Hatanaka RINEX file read from r covering
+// the time range [from, to] to w, without decoding and re-encoding the data.
+//
+// Since the initialization flag ('>' or '&') resets every differenced record,
+// the file is cut at the last initialization epoch at or before from (or the
+// first one after from if there is none), and before the first initialization
+// epoch after to. The compressed lines between them are copied as they are,
+// so the result may cover a slightly wider range than [from, to].
+// Initialization epochs where the clock offset continues the previous
+// differences are not used as the start of the cut.
+//
+// The header is copied with TIME OF FIRST OBS (and TIME OF LAST OBS if
+// present) rewritten. The result is decoded with a Scanner before written to
+// w, and nothing is written if the verification fails.
+func CutAtInit(w io.Writer, r io.Reader, from, to time.Time) (cut Cut, err error) {
+	c := cutter{s: newLineScanner(r, Options{})}
+
+	header, err := c.readHeader()
+	if err != nil {
+		return cut, err
+	}
+
+	data, cut, err := c.cut(from, to)
+	if err != nil {
+		return cut, err
+	}
+	if cut.Epochs == 0 {
+		return cut, ErrNoCutPoint
+	}
+
+	// fix up the header
+	lines := headerLines(header)
+	timeSys := ""
+	if l, ok := findHeaderLine(lines, labelFirstObs); ok {
+		_, timeSys, _ = parseObsTime(l)
+	}
+	lines = replaceHeaderLines(lines, labelFirstObs, labelEndOfHeader,
+		[]string{formatObsTime(cut.First, timeSys, labelFirstObs)})
+	if _, ok := findHeaderLine(lines, labelLastObs); ok {
+		lines = replaceHeaderLines(lines, labelLastObs, labelEndOfHeader,
+			[]string{formatObsTime(cut.Last, timeSys, labelLastObs)})
+	}
+	out := append(joinHeaderLines(lines), data...)
+
+	if err := verifyCut(out, cut); err != nil {
+		return cut, err
+	}
+
+	_, err = w.Write(out)
+	return cut, err
+}
+
+// cutter walks the lines of a Hatanaka RINEX file epoch by epoch.
+// Only the epoch record and the pico-second record are decoded to find the
+// time tags and the number of data lines.
+type cutter struct {
+	s       *bufio.Scanner
+	ver     string
+	lineNum int
+
+	epochRec strRecord
+	picoSec  strRecord
+}
+
+func (c *cutter) scan() bool {
+	ok := c.s.Scan()
+	if ok {
+		c.lineNum++
+	}
+	return ok
+}
+
+// readHeader reads the CRINEX and RINEX header lines.
+func (c *cutter) readHeader() (header []byte, err error) {
+	if !c.scan() {
+		if err := c.s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrBadMagic
+	}
+	if c.ver, err = parseMagic(c.s.Text()); err != nil {
+		return nil, err
+	}
+
+	header = append(header, c.s.Text()...)
+	header = append(header, '\n')
+	for c.scan() {
+		t := c.s.Text()
+		header = append(header, t...)
+		header = append(header, '\n')
+		if headerLabel(t) == labelEndOfHeader {
+			return header, nil
+		}
+	}
+	if err := c.s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: END OF HEADER not found", ErrInvalidHeader)
+}
+
+// cutEpoch holds the lines of an epoch.
+type cutEpoch struct {
+	lines    []byte
+	line     int // line number of the epoch record
+	epoch    time.Time
+	init     bool // starts with an initialization flag
+	cutPoint bool // the cut can start at the epoch
+	event    bool // special event
+}
+
+// cut returns the lines between the initialization epochs for [from, to].
+func (c *cutter) cut(from, to time.Time) (data []byte, cut Cut, err error) {
+	for {
+		e, err := c.next()
+		if err == io.EOF {
+			return data, cut, nil
+		}
+		if err != nil {
+			return nil, cut, err
+		}
+
+		switch {
+		case e.event:
+			// special events are copied within the cut
+		case cut.Epochs > 0 && e.init && e.epoch.After(to):
+			// end of the cut
+			return data, cut, nil
+		case e.cutPoint && (!e.epoch.After(from) || cut.Epochs == 0) && !e.epoch.After(to):
+			// (re)start the cut, while the epochs are not later than from
+			if cut.Epochs == 0 || !e.epoch.After(from) {
+				data = data[:0]
+				cut = Cut{First: e.epoch, StartLine: e.line}
+			}
+		case cut.Epochs == 0:
+			// not started
+			continue
+		}
+
+		data = append(data, e.lines...)
+		if !e.event {
+			cut.Last = e.epoch
+			cut.Epochs++
+		}
+		cut.EndLine = c.lineNum
+	}
+}
+
+// next reads the lines of the next epoch.
+func (c *cutter) next() (e cutEpoch, err error) {
+	if !c.scan() {
+		if err := c.s.Err(); err != nil {
+			return e, err
+		}
+		return e, io.EOF
+	}
+	t := c.s.Text()
+	e.line = c.lineNum
+
+	if isCRINEXMagic(t) {
+		// concatenated file is not cut
+		return e, io.EOF
+	}
+
+	appendLine := func() {
+		e.lines = append(e.lines, c.s.Text()...)
+		e.lines = append(e.lines, '\n')
+	}
+	scanLine := func() error {
+		if !c.scan() {
+			if err := c.s.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: line %d", ErrTruncated, c.lineNum)
+		}
+		appendLine()
+		return nil
+	}
+	appendLine()
+
+	// (1) epoch record
+	init, event, numSkip, err := checkInitialized(t)
+	if err != nil {
+		return e, fmt.Errorf("line %d: %w", c.lineNum, err)
+	}
+	if event {
+		e.event = true
+		for i := 0; i < numSkip; i++ {
+			if err := scanLine(); err != nil {
+				return e, err
+			}
+		}
+		return e, nil
+	}
+	if init {
+		c.epochRec.buf = []byte(t)
+	} else {
+		c.epochRec.Decode(t)
+	}
+	if e.epoch, err = epochRecBytestoTime(c.epochRec.Bytes(), c.ver); err != nil {
+		return e, fmt.Errorf("line %d: %w", c.lineNum, err)
+	}
+	e.init = init
+
+	// (2) clock offset and pico-second
+	if err := scanLine(); err != nil {
+		return e, err
+	}
+	vals := strings.SplitN(c.s.Text(), " ", 2)
+	clock := vals[0]
+	e.cutPoint = init && (clock == "" || (len(clock) > 1 && clock[1] == '&'))
+	if c.ver >= "3.1" && len(vals) >= 2 {
+		// the pico-second record must not depend on the previous epoch
+		var p strRecord
+		if vals[1] == "&" {
+			// the pico-second record is removed
+			c.picoSec = strRecord{}
+		} else {
+			p.Decode(vals[1])
+			c.picoSec.Decode(vals[1])
+		}
+		e.cutPoint = e.cutPoint && bytes.Equal(bytes.TrimRight(p.Bytes(), " "), bytes.TrimRight(c.picoSec.Bytes(), " "))
+	} else if len(bytes.TrimSpace(c.picoSec.Bytes())) > 0 {
+		e.cutPoint = false
+	}
+
+	// (3) observation data, one line for each valid satellite
+	satList, _, err := getSatListWithCorrection(c.epochRec.Bytes(), c.ver, e.line)
+	if err != nil {
+		return e, fmt.Errorf("line %d: %w", e.line, err)
+	}
+	for _, satId := range satList {
+		if !slices.Contains(VALID_SATSYS, satId[:1]) || strings.HasSuffix(satId, " ") {
+			continue
+		}
+		if err := scanLine(); err != nil {
+			return e, err
+		}
+	}
+
+	return e, nil
+}
+
+// verifyCut decodes b with a Scanner, and checks the epochs against cut.
+func verifyCut(b []byte, cut Cut) error {
+	s, err := NewScanner(bytes.NewReader(b))
+	if err == nil {
+		err = s.ParseHeader()
+	}
+	if err != nil {
+		return fmt.Errorf("crinex: verification of the cut failed: %w", err)
+	}
+
+	var (
+		n           int
+		first, last time.Time
+	)
+	for s.ScanEpoch() {
+		if n == 0 {
+			first = s.Epoch()
+		}
+		last = s.Epoch()
+		n++
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("crinex: verification of the cut failed: %w", err)
+	}
+	if n != cut.Epochs || !first.Equal(cut.First) || !last.Equal(cut.Last) {
+		return fmt.Errorf("crinex: verification of the cut failed: %d epochs %v - %v decoded, %d epochs %v - %v expected",
+			n, first, last, cut.Epochs, cut.First, cut.Last)
+	}
+	return nil
+}
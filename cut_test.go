@@ -0,0 +1,88 @@
+package crinex
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCutAtInit(t *testing.T) {
+	b, err := os.ReadFile("testdata/event_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(min, sec int) time.Time { return time.Date(2023, 1, 1, 0, min, sec, 0, time.UTC) }
+
+	// initialization epochs of event_v3.crx at 00:00:00 (line 10) and
+	// 00:01:30 (line 22)
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     Cut
+		err      error
+	}{
+		{"first", at(0, 0), at(0, 30), Cut{First: at(0, 0), Last: at(0, 30), Epochs: 2, StartLine: 10, EndLine: 21}, nil},
+		{"middle", at(0, 30), at(0, 30), Cut{First: at(0, 0), Last: at(0, 30), Epochs: 2, StartLine: 10, EndLine: 21}, nil},
+		{"last", at(1, 30), at(2, 0), Cut{First: at(1, 30), Last: at(1, 30), Epochs: 1, StartLine: 22, EndLine: 26}, nil},
+		{"all", at(0, 0), at(2, 0), Cut{First: at(0, 0), Last: at(1, 30), Epochs: 3, StartLine: 10, EndLine: 26}, nil},
+		{"before", at(-10, 0), at(-5, 0), Cut{}, ErrNoCutPoint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cut, err := CutAtInit(&out, bytes.NewReader(b), tt.from, tt.to)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				if out.Len() > 0 {
+					t.Errorf("written on error:\n%s", out.Bytes())
+				}
+				return
+			}
+			if cut != tt.want {
+				t.Errorf("cut = %+v, want %+v", cut, tt.want)
+			}
+
+			// the compressed lines are copied as they are
+			lines := bytes.SplitAfter(b, []byte("\n"))
+			data := bytes.Join(lines[cut.StartLine-1:cut.EndLine], nil)
+			if !bytes.HasSuffix(out.Bytes(), data) {
+				t.Errorf("output:\n%s\nwant the lines %d-%d:\n%s", out.Bytes(), cut.StartLine, cut.EndLine, data)
+			}
+
+			// TIME OF FIRST OBS is rewritten
+			s, err := NewScanner(bytes.NewReader(out.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.ParseHeader(); err != nil {
+				t.Fatal(err)
+			}
+			first := formatObsTime(tt.want.First, "GPS", labelFirstObs)
+			if !bytes.Contains(s.Header(), []byte(first)) {
+				t.Errorf("header:\n%s\nwant %s", s.Header(), first)
+			}
+		})
+	}
+}
+
+func TestCutAtInitPicoSecRemoved(t *testing.T) {
+	// the pico-second record removed at 00:00:30, and the initialization
+	// epoch without the record at 00:01:30 (line 20)
+	b := append(firstLines(t, "testdata/picosec_v31.crx", 19),
+		bytes.Join(bytes.SplitAfter(firstLines(t, "testdata/event_v3.crx", 26), []byte("\n"))[21:], nil)...)
+
+	at := time.Date(2023, 1, 1, 0, 1, 30, 0, time.UTC)
+	var out bytes.Buffer
+	cut, err := CutAtInit(&out, bytes.NewReader(b), at, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Cut{First: at, Last: at, Epochs: 1, StartLine: 20, EndLine: 24}); cut != want {
+		t.Errorf("cut = %+v, want %+v", cut, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -32,26 +33,75 @@ func (e *strRecord) StringRINEX() string {
 	return s
 }
 
+// BytesRINEX returns the RINEX 3/4 epoch record with the clock offset clk
+// (NaN if missing) and the pico-second record pico (nil if missing).
+func (e *strRecord) BytesRINEX(clk float64, pico []byte) []byte {
+	hasClock := !math.IsNaN(clk)
+	hasPicoSec := pico != nil
+
+	switch {
+	case !hasClock && !hasPicoSec:
+		return []byte(fmt.Sprintf("%-35.35s\n", e.StringRINEX()))
+	case hasClock && !hasPicoSec:
+		return []byte(fmt.Sprintf("%-35.35s      %15.12f\n", e.StringRINEX(), clk))
+	case !hasClock && hasPicoSec:
+		return []byte(fmt.Sprintf("%-35.35s                      %5.5s\n", e.StringRINEX(), pico))
+	default:
+		return []byte(fmt.Sprintf("%-35.35s      %15.12f %5.5s\n", e.StringRINEX(), clk, pico))
+	}
+}
+
+// picoSecondsBytes returns the pico-second record b as [5]byte and a bool
+// indicating success. Records other than 5 digits are format violations.
+func picoSecondsBytes(b []byte) (bytes [5]byte, ok bool) {
+	if len(b) != 5 {
+		return bytes, false
+	}
+
+	for i, c := range b {
+		if !isNumeric(c) {
+			// format violation
+			return bytes, false
+		}
+		bytes[i] = c
+	}
+	return bytes, true
+}
+
 func (e *strRecord) StringRINEXV2(clk float64) string {
-	var b []byte
+	var (
+		b     []byte
+		buf   = e.buf
+		space = []byte{' '}
+	)
+
+	numSat := 0
+	if len(buf) >= 32 {
+		numSat, _ = strconv.Atoi(string(bytes.TrimSpace(buf[29:32])))
+	}
+	if numSat < 0 {
+		numSat = 0
+	}
 
-	numSat, err := strconv.Atoi(string(bytes.TrimSpace(e.buf[29:32])))
-	_ = err
+	// pad the record with spaces to hold the satellite list of numSat satellites
+	if n := 32 + 3*numSat; len(buf) < n {
+		buf = append(slices.Clone(buf), bytes.Repeat(space, n-len(buf))...)
+	}
 
 	// first line
 	if numSat > 12 {
 		if math.IsNaN(clk) {
 			// clock is missing
-			b = append(b, fmt.Sprintf(" %67s\n", string(e.buf[1:68]))...)
+			b = append(b, fmt.Sprintf(" %67s\n", string(buf[1:68]))...)
 		} else {
-			b = append(b, fmt.Sprintf(" %67s%12.9f\n", string(e.buf[1:68]), clk)...)
+			b = append(b, fmt.Sprintf(" %67s%12.9f\n", string(buf[1:68]), clk)...)
 		}
 	} else {
 		if math.IsNaN(clk) {
 			// clock is missing
-			b = append(b, fmt.Sprintf(" %s\n", e.buf[1:32+3*numSat])...)
+			b = append(b, fmt.Sprintf(" %s\n", buf[1:32+3*numSat])...)
 		} else {
-			b = append(b, fmt.Sprintf(" %-67s%12.9f\n", e.buf[1:32+3*numSat], clk)...)
+			b = append(b, fmt.Sprintf(" %-67s%12.9f\n", buf[1:32+3*numSat], clk)...)
 		}
 		return string(b)
 
@@ -60,9 +110,9 @@ func (e *strRecord) StringRINEXV2(clk float64) string {
 	// continuation lines
 	for i := 1; numSat > 12*i; i++ {
 		if numSat >= 12*(i+1) {
-			b = append(b, fmt.Sprintf("%32s%-36.36s\n", "", e.buf[32+36*i:32+36*(i+1)])...)
+			b = append(b, fmt.Sprintf("%32s%-36.36s\n", "", buf[32+36*i:32+36*(i+1)])...)
 		} else {
-			b = append(b, fmt.Sprintf("%32s%-s\n", "", e.buf[32+36*i:32+36*i+3*(numSat%12)])...)
+			b = append(b, fmt.Sprintf("%32s%-s\n", "", buf[32+36*i:32+36*i+3*(numSat%12)])...)
 		}
 	}
 
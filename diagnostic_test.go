@@ -0,0 +1,111 @@
+package crinex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// diagnosed returns example_v3.crx with a header line without label and an
+// epoch record with an extra space before the satellite list.
+func diagnosed(t *testing.T) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/example_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := strings.Replace(string(b), "TEST                                                        MARKER NAME\n",
+		"TEST                                                        MARKER NAME\nno label\n", 1)
+	s = strings.Replace(s, "  0  3      G01G02R03\n", "  0  3       G01G02R03\n", 1)
+	return []byte(s)
+}
+
+func TestOnDiagnostic(t *testing.T) {
+	var diags []Diagnostic
+	s, err := NewScannerWithOptions(bytes.NewReader(diagnosed(t)), Options{
+		OnDiagnostic: func(d Diagnostic) { diags = append(diags, d) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	epochs := 0
+	for s.ScanEpoch() {
+		epochs++
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if epochs != 3 {
+		t.Errorf("epochs = %d, want 3", epochs)
+	}
+
+	// passed to OnDiagnostic instead of Warnings
+	if len(s.Warnings) != 0 || len(s.Diagnostics) != 0 {
+		t.Errorf("Warnings = %v, Diagnostics = %v", s.Warnings, s.Diagnostics)
+	}
+	// the extra space is kept in the differenced epoch records
+	type diag struct {
+		code     Code
+		severity Severity
+		pos      int
+	}
+	want := []diag{{CodeNoHeaderLabel, SeverityWarning, 5}}
+	for _, pos := range []int{11, 16, 21} {
+		want = append(want, diag{CodeEpochRecLength, SeverityWarning, pos}, diag{CodeSatListRepaired, SeverityWarning, pos})
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("diagnostics = %+v", diags)
+	}
+	for i, d := range diags {
+		w := want[i]
+		if d.Code != w.code || d.Severity != w.severity || d.Pos != w.pos {
+			t.Errorf("diagnostic %d = %+v, want %s %s at line %d", i, d, w.code, w.severity, w.pos)
+		}
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	s, err := NewScanner(bytes.NewReader(diagnosed(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s.ScanEpoch() {
+	}
+	if len(s.Diagnostics) != 7 || s.Diagnostics[0].Code != CodeNoHeaderLabel || s.Diagnostics[2].Code != CodeSatListRepaired {
+		t.Fatalf("Diagnostics = %v", s.Diagnostics)
+	}
+
+	// the Warning of each diagnostic
+	if len(s.Warnings) != len(s.Diagnostics) {
+		t.Fatalf("Warnings = %v", s.Warnings)
+	}
+	for i, w := range s.Warnings {
+		if d := s.Diagnostics[i]; *w != (Warning{d.Pos, d.Msg}) || w.String() != fmt.Sprintf("pos:%d, msg:%s", d.Pos, d.Msg) {
+			t.Errorf("warning %d = %v, want the position and the message of %v", i, w, d)
+		}
+	}
+}
+
+func TestAbort(t *testing.T) {
+	var s *Scanner
+	s, err := NewScannerWithOptions(bytes.NewReader(diagnosed(t)), Options{
+		OnDiagnostic: func(d Diagnostic) {
+			if d.Code == CodeSatListRepaired {
+				s.Abort(nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	epochs := 0
+	for s.ScanEpoch() {
+		epochs++
+	}
+	if epochs != 0 || !errors.Is(s.Err(), ErrAborted) {
+		t.Errorf("%d epochs, err = %v, want 0 epochs and ErrAborted", epochs, s.Err())
+	}
+}
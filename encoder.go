@@ -0,0 +1,292 @@
+package crinex
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------
+// Hatanaka RINEX encoder
+// ---------------------------------------------------
+
+// orders of difference used by the encoder, same as RNX2CRX
+const (
+	encoderDataOrder  = 3
+	encoderClockOrder = 2
+)
+
+// encoder encodes epochs decoded by a Scanner into Hatanaka RINEX.
+//
+// The state of the encoder follows the state of the decoder in Scanner, so
+// that the output is decoded to the same RINEX data. The first epoch, and
+// every epoch after forceInit, is written with an initialization flag.
+type encoder struct {
+	ver string // CRINEX version
+
+	init     bool   // the next epoch is written with an initialization flag
+	epochRec []byte // previous epoch record
+	clk      diffEncoder
+	picoSec  []byte
+	sats     map[string]*satEncoder
+}
+
+// satEncoder holds the state of a satellite.
+type satEncoder struct {
+	data    []diffEncoder
+	lli, ss []byte
+}
+
+// diffEncoder holds the previous values of an arc for the differences.
+type diffEncoder struct {
+	order int
+	hist  []int64 // previous values, the latest at the end
+}
+
+// newEncoder returns a new encoder for the CRINEX version ver.
+func newEncoder(ver string) *encoder {
+	return &encoder{ver: ver, init: true}
+}
+
+// forceInit makes the next epoch to be written with an initialization flag.
+func (e *encoder) forceInit() {
+	e.init = true
+}
+
+// crinexHeader returns the CRINEX header lines followed by the RINEX header.
+func (e *encoder) crinexHeader(header []byte, date time.Time) []byte {
+	lines := []string{
+		formatHeaderLine(fmt.Sprintf("%-20s%-20s", e.ver, "COMPACT RINEX FORMAT"), "CRINEX VERS   / TYPE"),
+		formatHeaderLine(fmt.Sprintf("%-40s%s", "crinex", date.UTC().Format("02-Jan-06 15:04")), "CRINEX PROG / DATE"),
+	}
+	return append(joinHeaderLines(lines), header...)
+}
+
+// encode returns the current epoch of s encoded in Hatanaka RINEX.
+func (e *encoder) encode(s *Scanner) []byte {
+	return e.encodeObs(s, s.Data())
+}
+
+// encodeObs returns the current epoch of s encoded in Hatanaka RINEX with the
+// observables data, e.g. the data of s arranged in other obstypes.
+func (e *encoder) encodeObs(s *Scanner, data []SatObss) []byte {
+	var buf []byte
+
+	satList, obs := e.satellites(data)
+	rec := e.epochRecord(s.epochRec.Bytes(), satList)
+
+	// (1) epoch record
+	if e.init {
+		e.sats = make(map[string]*satEncoder)
+		e.clk = diffEncoder{}
+		e.picoSec = nil
+		buf = append(buf, rec...)
+	} else {
+		buf = append(buf, diffText(e.epochRec, rec)...)
+	}
+	buf = append(buf, '\n')
+	e.epochRec = rec
+
+	// (2) clock offset and pico-second
+	if s.clk.missing {
+		e.clk.reset()
+	} else {
+		buf = append(buf, e.clk.encode(s.clk.refData, encoderClockOrder)...)
+	}
+	if e.ver >= "3.1" {
+		pico := s.picoSec.Bytes()
+		switch d := diffText(e.picoSec, pico); {
+		case len(pico) == 0 && len(e.picoSec) > 0:
+			// removed field, written as a single '&' as in RNX2CRX
+			buf = append(buf, " &"...)
+		case len(d) > 0:
+			buf = append(buf, ' ')
+			buf = append(buf, d...)
+		}
+		e.picoSec = slices.Clone(pico)
+	}
+	buf = append(buf, '\n')
+
+	// (3) observation data
+	for _, satId := range satList {
+		buf = append(buf, e.encodeSat(satId, obs[satId])...)
+		buf = append(buf, '\n')
+	}
+
+	// arcs of the satellites not observed are re-initialized
+	for satId, sat := range e.sats {
+		if !slices.Contains(satList, satId) {
+			for j := range sat.data {
+				sat.data[j].reset()
+			}
+		}
+	}
+
+	e.init = false
+	return buf
+}
+
+// events returns the special events of s preceding the current epoch in
+// Hatanaka RINEX. The event records are written as they are, except that the
+// records of CRINEX 1.0 begin with '&'. The next epoch is written with an
+// initialization flag, as required after a special event.
+func (e *encoder) events(s *Scanner) (buf []byte) {
+	for _, ev := range s.Events() {
+		rec := eventAsBytes(ev.rec, s.ver)
+		if e.ver == "1.0" && len(rec) > 0 {
+			rec[0] = '&'
+		}
+		buf = append(buf, rec...)
+		buf = append(buf, '\n')
+		for _, r := range ev.Records {
+			buf = append(buf, r...)
+			buf = append(buf, '\n')
+		}
+		e.init = true
+	}
+	return buf
+}
+
+// satellites returns the valid satellites of data, and the observables of the
+// satellites.
+func (e *encoder) satellites(data []SatObss) (satList []string, obs map[string]SatObss) {
+	obs = make(map[string]SatObss)
+	for _, o := range data {
+		satId := o.SatId
+		if !slices.Contains(VALID_SATSYS, satId[:1]) || strings.HasSuffix(satId, " ") {
+			continue
+		}
+		satList = append(satList, satId)
+		obs[satId] = o
+	}
+	return
+}
+
+// epochRecord returns the epoch record with the satellite list satList and
+// the initialization flag of the version.
+func (e *encoder) epochRecord(rec []byte, satList []string) []byte {
+	offsetNumSat, offsetSatList := OFFSET_NUMSAT_V3, OFFSET_SATLST_V3
+	if e.ver == "1.0" {
+		offsetNumSat, offsetSatList = OFFSET_NUMSAT_V1, OFFSET_SATLST_V1
+	}
+
+	r := slices.Clone(rec)
+	if len(r) < offsetSatList {
+		r = append(r, bytes.Repeat([]byte{' '}, offsetSatList-len(r))...)
+	}
+	r = r[:offsetSatList]
+	copy(r[offsetNumSat:], fmt.Sprintf("%3d", len(satList)))
+	for _, satId := range satList {
+		r = append(r, satId...)
+	}
+	return r
+}
+
+// encodeSat returns the data line of a satellite.
+func (e *encoder) encodeSat(satId string, o SatObss) []byte {
+	n := len(o.ObsData)
+	sat, ok := e.sats[satId]
+	if !ok || len(sat.data) != n {
+		sat = &satEncoder{
+			data: make([]diffEncoder, n),
+			lli:  bytes.Repeat([]byte{' '}, n),
+			ss:   bytes.Repeat([]byte{' '}, n),
+		}
+		e.sats[satId] = sat
+	}
+
+	var (
+		fields = make([]string, n)
+		flags  = bytes.Repeat([]byte{' '}, 2*n)
+	)
+	for j, d := range o.ObsData {
+		if math.IsNaN(d.Data) {
+			sat.data[j].reset()
+			continue
+		}
+
+		if e.ver == "1.0" && len(sat.data[j].hist) == 0 {
+			// LLI and SS are reset at the beginning of an arc
+			sat.lli[j], sat.ss[j] = ' ', ' '
+		}
+		fields[j] = sat.data[j].encode(int64(math.Round(d.Data*1000)), encoderDataOrder)
+
+		flags[2*j] = diffByte(sat.lli[j], d.LLI)
+		flags[2*j+1] = diffByte(sat.ss[j], d.SS)
+		sat.lli[j], sat.ss[j] = d.LLI, d.SS
+	}
+
+	line := []byte(strings.Join(fields, " "))
+	if f := bytes.TrimRight(flags, " "); len(f) > 0 {
+		line = append(line, ' ')
+		line = append(line, f...)
+		return line
+	}
+	return bytes.TrimRight(line, " ")
+}
+
+// encode returns v as the initialization of the arc, or as the difference
+// from the previous values.
+func (d *diffEncoder) encode(v int64, order int) string {
+	if len(d.hist) == 0 {
+		d.order = order
+		d.hist = append(d.hist, v)
+		return fmt.Sprintf("%d&%d", order, v)
+	}
+
+	d.hist = append(d.hist, v)
+	if len(d.hist) > d.order+1 {
+		d.hist = d.hist[1:]
+	}
+
+	// difference of the order len(hist)-1
+	dv := slices.Clone(d.hist)
+	for len(dv) > 1 {
+		for i := 0; i < len(dv)-1; i++ {
+			dv[i] = dv[i+1] - dv[i]
+		}
+		dv = dv[:len(dv)-1]
+	}
+	return fmt.Sprint(dv[0])
+}
+
+// reset makes the next value to initialize the arc.
+func (d *diffEncoder) reset() {
+	d.hist = d.hist[:0]
+}
+
+// diffText returns the text difference of cur from prev, as decoded by
+// strRecord.Decode.
+func diffText(prev, cur []byte) []byte {
+	n := len(cur)
+	if len(prev) > n {
+		n = len(prev)
+	}
+
+	d := make([]byte, n)
+	for i := range d {
+		p, c := byte(' '), byte(' ')
+		if i < len(prev) {
+			p = prev[i]
+		}
+		if i < len(cur) {
+			c = cur[i]
+		}
+		d[i] = diffByte(p, c)
+	}
+	return bytes.TrimRight(d, " ")
+}
+
+// diffByte returns the text difference of a byte.
+func diffByte(prev, cur byte) byte {
+	switch {
+	case prev == cur:
+		return ' '
+	case cur == ' ':
+		return '&'
+	}
+	return cur
+}
@@ -0,0 +1,68 @@
+package crinex
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// encodeAll re-encodes the CRINEX file b with the encoder.
+func encodeAll(t *testing.T, b []byte) []byte {
+	t.Helper()
+	s, err := NewScanner(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ParseHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	enc := newEncoder(s.ver)
+	out := enc.crinexHeader(s.Header(), time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+	for s.ScanEpoch() {
+		out = append(out, enc.encode(s)...)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestEncoderRoundTrip(t *testing.T) {
+	for _, name := range []string{"example_v1.crx", "example_v3.crx", "picosec_v31.crx"} {
+		t.Run(name, func(t *testing.T) {
+			b, err := os.ReadFile("testdata/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := convertBytes(t, b)
+			got := convertBytes(t, encodeAll(t, b))
+			if !bytes.Equal(got, want) {
+				t.Errorf("round trip:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestEncoderPicoSecRemoved(t *testing.T) {
+	b, err := os.ReadFile("testdata/picosec_v31.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// clock lines of the three epochs
+	lines := strings.Split(string(encodeAll(t, b)), "\n")
+	for i, want := range map[int]string{10: " 12345", 15: " &", 20: " 54321"} {
+		if lines[i] != want {
+			t.Errorf("line %d = %q, want %q", i+1, lines[i], want)
+		}
+	}
+
+	// the epoch without the pico-second record
+	rnx := convertBytes(t, b)
+	if !bytes.Contains(rnx, []byte("> 2023 01 01 00 00 30.0000000  0  3\n")) {
+		t.Errorf("pico-second record not removed:\n%s", rnx)
+	}
+}
@@ -0,0 +1,78 @@
+package crinex
+
+import (
+	"strconv"
+	"time"
+)
+
+// Event is a special event record (epoch flag 2-6) that precedes an epoch.
+// Special events are not returned as epochs by ScanEpoch.
+type Event struct {
+	Flag    int       // epoch flag
+	Epoch   time.Time // time tag, zero if not given
+	Line    int       // line number of the event record
+	Records []string  // records following the event record, e.g. header lines
+
+	rec string // event record
+}
+
+// newEvent returns the Event of the special event record t at the current
+// line.
+func (s *Scanner) newEvent(t string) Event {
+	ev := Event{Line: s.lineNum, rec: t}
+	ev.Flag = epochFlag([]byte(t), s.ver)
+	ev.Epoch, _ = epochRecBytestoTime([]byte(t), s.ver)
+	return ev
+}
+
+// Events returns the special events found after the previous epoch and
+// before the current epoch.
+func (s *Scanner) Events() []Event {
+	return s.events
+}
+
+// EventsAsBytes returns the special events of Events in RINEX: the event
+// records and the records following them.
+func (s *Scanner) EventsAsBytes() (buf []byte) {
+	for _, ev := range s.events {
+		buf = append(buf, eventAsBytes(ev.rec, s.ver)...)
+		buf = append(buf, '\n')
+		for _, r := range ev.Records {
+			buf = append(buf, r...)
+			buf = append(buf, '\n')
+		}
+	}
+	return buf
+}
+
+// eventAsBytes returns the special event record of CRINEX in RINEX. The event
+// records of CRINEX 1.0 begin with '&' in place of the blank of RINEX 2.
+func eventAsBytes(rec, ver string) []byte {
+	b := []byte(rec)
+	if ver == "1.0" && len(b) > 0 && b[0] == '&' {
+		b[0] = ' '
+	}
+	return b
+}
+
+// EpochFlag returns the epoch flag of the current epoch: 0 for OK, or 1 for
+// a power failure between the previous and the current epoch.
+func (s *Scanner) EpochFlag() int {
+	return epochFlag(s.epochRec.Bytes(), s.ver)
+}
+
+// epochFlag returns the epoch flag of the epoch record, or 0 if not given.
+func epochFlag(rec []byte, ver string) int {
+	i := 31 // crx v3.0
+	if ver == "1.0" {
+		i = 28
+	}
+	if len(rec) <= i {
+		return 0
+	}
+	flag, err := strconv.Atoi(string(rec[i]))
+	if err != nil {
+		return 0
+	}
+	return flag
+}
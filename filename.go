@@ -0,0 +1,459 @@
+package crinex
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------
+// RINEX file names
+// ---------------------------------------------------
+
+// ErrInvalidFileName is returned by ParseFileName if the name follows neither
+// the short nor the long RINEX file name convention.
+var ErrInvalidFileName = errors.New("crinex: Invalid file name")
+
+// FileName represents a RINEX file name in the short convention of RINEX 2,
+// "SSSSDDDF.YYt" (or "SSSSDDDHMM.YYt" for high-rate files), or in the long
+// convention of RINEX 3/4, "SSSSMRCCC_S_YYYYDDDHHMM_PPU_FFU_CT.fmt".
+type FileName struct {
+	Long bool // long name convention
+
+	Station  string // 4 character station ID
+	Monument int    // monument number, long names only
+	Receiver int    // receiver number, long names only
+	Country  string // ISO 3166 country code, long names only
+	Source   string // data source, "R", "S" or "U", long names only
+
+	Start    time.Time     // start time of the file
+	Period   time.Duration // file period, e.g. 24*time.Hour
+	Interval time.Duration // data sampling interval, 0 if not given
+
+	// Content is the satellite system and the data type, e.g. "MO" for mixed
+	// observations or "GN" for GPS navigation data. The satellite system of
+	// a short name is "M" for observations.
+	Content  string
+	Format   string // "crx" for Hatanaka RINEX, or "rnx"
+	Compress string // compression suffix, e.g. "gz", empty if not compressed
+}
+
+// ParseFileName parses a short or long RINEX file name. Directories are not
+// allowed in name.
+func ParseFileName(name string) (f FileName, err error) {
+	base := name
+	for _, c := range []string{"gz", "Z", "bz2", "zip"} {
+		if strings.HasSuffix(base, "."+c) {
+			f.Compress = c
+			base = strings.TrimSuffix(base, "."+c)
+			break
+		}
+	}
+
+	if strings.Contains(base, "_") {
+		err = f.parseLong(base)
+	} else {
+		err = f.parseShort(base)
+	}
+	if err != nil {
+		return f, fmt.Errorf("%w: %s: %s", ErrInvalidFileName, name, err)
+	}
+	return f, nil
+}
+
+// parseLong parses the long name without the compression suffix, e.g.
+// "ABCD00JPN_R_20230010000_01D_30S_MO.crx".
+func (f *FileName) parseLong(base string) (err error) {
+	f.Long = true
+
+	name, ext, ok := strings.Cut(base, ".")
+	if !ok {
+		return errors.New("no format extension")
+	}
+	f.Format = ext
+
+	fields := strings.Split(name, "_")
+	if len(fields) != 5 && len(fields) != 6 {
+		return fmt.Errorf("%d fields", len(fields))
+	}
+
+	// SSSSMRCCC
+	st := fields[0]
+	if len(st) != 9 || !isDigits(st[4:6]) {
+		return fmt.Errorf("invalid station '%s'", st)
+	}
+	f.Station, f.Country = st[:4], st[6:]
+	f.Monument, f.Receiver = int(st[4]-'0'), int(st[5]-'0')
+
+	// S
+	if len(fields[1]) != 1 {
+		return fmt.Errorf("invalid data source '%s'", fields[1])
+	}
+	f.Source = fields[1]
+
+	// YYYYDDDHHMM
+	if f.Start, err = parseFileTime(fields[2]); err != nil {
+		return err
+	}
+
+	// PPU
+	if f.Period, err = parseFileDuration(fields[3]); err != nil {
+		return fmt.Errorf("invalid period '%s'", fields[3])
+	}
+
+	// FFU, not given for navigation files
+	if len(fields) == 6 {
+		if f.Interval, err = parseFileDuration(fields[4]); err != nil {
+			return fmt.Errorf("invalid frequency '%s'", fields[4])
+		}
+	}
+
+	// CT
+	f.Content = fields[len(fields)-1]
+	if len(f.Content) != 2 {
+		return fmt.Errorf("invalid content '%s'", f.Content)
+	}
+	return nil
+}
+
+// parseShort parses the short name without the compression suffix, e.g.
+// "abcd0010.23d" or "abcd001a15.23d".
+func (f *FileName) parseShort(base string) (err error) {
+	name, ext, ok := strings.Cut(base, ".")
+	if !ok || len(ext) != 3 || !isDigits(ext[:2]) {
+		return errors.New("invalid extension")
+	}
+	if len(name) != 8 && len(name) != 10 {
+		return errors.New("invalid length")
+	}
+
+	f.Station = name[:4]
+	doy, err := strconv.Atoi(name[4:7])
+	if err != nil || doy < 1 || doy > 366 {
+		return fmt.Errorf("invalid day of year '%s'", name[4:7])
+	}
+
+	// 2 digits year: 80-99 for 1980-1999, 00-79 for 2000-2079
+	year, _ := strconv.Atoi(ext[:2])
+	if year < 80 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	f.Start = time.Date(year, 1, doy, 0, 0, 0, 0, time.UTC)
+
+	// session: '0' for daily files, 'a'-'x' for hourly files, followed by
+	// the minutes for high-rate files
+	session := strings.ToLower(name[7:8])[0]
+	switch {
+	case session == '0' && len(name) == 8:
+		f.Period = 24 * time.Hour
+	case 'a' <= session && session <= 'x':
+		f.Start = f.Start.Add(time.Duration(session-'a') * time.Hour)
+		f.Period = time.Hour
+		if len(name) == 10 {
+			minute, err := strconv.Atoi(name[8:10])
+			if err != nil || minute >= 60 {
+				return fmt.Errorf("invalid minutes '%s'", name[8:10])
+			}
+			f.Start = f.Start.Add(time.Duration(minute) * time.Minute)
+			f.Period = 15 * time.Minute
+		}
+	default:
+		return fmt.Errorf("invalid session '%s'", name[7:])
+	}
+
+	switch strings.ToLower(ext[2:]) {
+	case "d":
+		f.Content, f.Format = "MO", "crx"
+	case "o":
+		f.Content, f.Format = "MO", "rnx"
+	case "n":
+		f.Content, f.Format = "GN", "rnx"
+	case "g":
+		f.Content, f.Format = "RN", "rnx"
+	case "l":
+		f.Content, f.Format = "EN", "rnx"
+	case "m":
+		f.Content, f.Format = "MM", "rnx"
+	default:
+		return fmt.Errorf("unknown file type '%s'", ext[2:])
+	}
+	return nil
+}
+
+// String returns the file name in the convention it was parsed from.
+func (f FileName) String() string {
+	if f.Long {
+		return f.LongName()
+	}
+	return f.ShortName()
+}
+
+// LongName returns the file name in the long convention, e.g.
+// "ABCD00JPN_R_20230010000_01D_30S_MO.crx.gz". Empty fields are filled with
+// the defaults, "XXX" for the country and "R" for the data source.
+func (f FileName) LongName() string {
+	country := strings.ToUpper(f.Country)
+	if country == "" {
+		country = "XXX"
+	}
+	source := f.Source
+	if source == "" {
+		source = "R"
+	}
+	station := strings.ReplaceAll(fmt.Sprintf("%-4.4s%d%d%-3.3s",
+		strings.ToUpper(f.Station), f.Monument, f.Receiver, country), " ", "X")
+
+	s := fmt.Sprintf("%s_%s_%04d%03d%02d%02d_%s", station, source,
+		f.Start.Year(), f.Start.YearDay(), f.Start.Hour(), f.Start.Minute(), formatFileDuration(f.Period))
+	if f.Interval > 0 || f.isObs() {
+		s += "_" + formatFileDuration(f.Interval)
+	}
+	s += "_" + f.Content + "." + f.Format
+	if f.Compress != "" {
+		s += "." + f.Compress
+	}
+	return s
+}
+
+// ShortName returns the file name in the short convention, e.g.
+// "abcd0010.23d.gz". Files shorter than an hour are named as high-rate files.
+func (f FileName) ShortName() string {
+	session := "0"
+	switch {
+	case f.Period > 0 && f.Period < time.Hour:
+		session = fmt.Sprintf("%c%02d", 'a'+f.Start.Hour(), f.Start.Minute())
+	case f.Period > 0 && f.Period < 24*time.Hour:
+		session = string(rune('a' + f.Start.Hour()))
+	}
+
+	var t string
+	switch {
+	case f.isObs() && f.Format == "crx":
+		t = "d"
+	case f.isObs():
+		t = "o"
+	case f.Content == "MM":
+		t = "m"
+	case f.Content == "RN":
+		t = "g"
+	case f.Content == "EN":
+		t = "l"
+	default:
+		t = "n"
+	}
+
+	s := fmt.Sprintf("%-4.4s%03d%s.%02d%s", strings.ToLower(f.Station),
+		f.Start.YearDay(), session, f.Start.Year()%100, t)
+	if f.Compress != "" {
+		s += "." + f.Compress
+	}
+	return s
+}
+
+// isObs reports whether the file is an observation file.
+func (f FileName) isObs() bool {
+	return len(f.Content) == 2 && f.Content[1] == 'O'
+}
+
+// NewFileName returns the long file name of the observation file with the
+// RINEX header, starting at start and covering period.
+func NewFileName(header []byte, start time.Time, period time.Duration) FileName {
+	lines := headerLines(header)
+	f := FileName{
+		Long:    true,
+		Start:   start,
+		Period:  period,
+		Content: "MO",
+		Format:  "rnx",
+	}
+
+	// station, monument and receiver numbers, and country
+	if l, ok := findHeaderLine(lines, "MARKER NAME"); ok {
+		m := strings.ToUpper(trimHeaderValue(l, 0, 60))
+		f.Station = m
+		if len(m) == 9 && isDigits(m[4:6]) {
+			f.Station, f.Country = m[:4], m[6:]
+			f.Monument, f.Receiver = int(m[4]-'0'), int(m[5]-'0')
+		}
+	}
+
+	if v, ok := headerInterval(lines); ok {
+		f.Interval = time.Duration(v * float64(time.Second))
+	}
+
+	// satellite system: G, R, E, ... or M for mixed
+	if l, ok := findHeaderLine(lines, labelRinexVersion); ok {
+		switch sys := trimHeaderValue(l, 40, 41); sys {
+		case "":
+			f.Content = "GO"
+		default:
+			f.Content = sys + "O"
+		}
+	}
+	return f
+}
+
+// File name diagnostics returned by FileName.Check.
+const (
+	CodeFileNameMarker   Code = "filename-marker"   // station differs from MARKER NAME
+	CodeFileNameStart    Code = "filename-start"    // TIME OF FIRST OBS is out of the file period
+	CodeFileNameInterval Code = "filename-interval" // sampling differs from INTERVAL
+)
+
+// Check cross-checks the file name against the RINEX header, and returns a
+// Diagnostic for every mismatch of the marker name, the time of the first
+// observation and the interval. Pos of the diagnostics is the line number in
+// the RINEX header.
+func (f FileName) Check(header []byte) (diags []Diagnostic) {
+	lines := headerLines(header)
+	mismatch := func(code Code, line, format string, a ...any) {
+		diags = append(diags, Diagnostic{
+			Code:     code,
+			Severity: SeverityWarning,
+			Pos:      slices.Index(lines, line) + 1,
+			Line:     line,
+			Msg:      fmt.Sprintf(format, a...),
+		})
+	}
+
+	if l, ok := findHeaderLine(lines, "MARKER NAME"); ok {
+		m := strings.ToUpper(trimHeaderValue(l, 0, 60))
+		switch {
+		case len(m) < 4 || !strings.EqualFold(m[:4], f.Station):
+			mismatch(CodeFileNameMarker, l, "station '%s' differs from marker name '%s'", f.Station, m)
+		case f.Long && len(m) == 9 && m[4:] != fmt.Sprintf("%d%d%s", f.Monument, f.Receiver, strings.ToUpper(f.Country)):
+			mismatch(CodeFileNameMarker, l, "station '%s' differs from marker name '%s'", f.LongName()[:9], m)
+		}
+	}
+
+	if l, ok := findHeaderLine(lines, labelFirstObs); ok {
+		if t, _, err := parseObsTime(l); err == nil && (t.Before(f.Start) || !t.Before(f.Start.Add(f.Period))) {
+			mismatch(CodeFileNameStart, l, "first observation %s is out of the file period %s - %s",
+				t.Format(time.DateTime), f.Start.Format(time.DateTime), f.Start.Add(f.Period).Format(time.DateTime))
+		}
+	}
+
+	if v, ok := headerInterval(lines); ok && f.Interval > 0 {
+		if d := time.Duration(v * float64(time.Second)); d != f.Interval {
+			l, _ := findHeaderLine(lines, "INTERVAL")
+			mismatch(CodeFileNameInterval, l, "sampling %v differs from interval %v", f.Interval, d)
+		}
+	}
+	return
+}
+
+// headerInterval returns the INTERVAL in seconds.
+func headerInterval(lines []string) (float64, bool) {
+	l, ok := findHeaderLine(lines, "INTERVAL")
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(trimHeaderValue(l, 0, 10), 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// parseFileTime parses the start time of the long name, "YYYYDDDHHMM".
+func parseFileTime(s string) (time.Time, error) {
+	if len(s) != 11 || !isDigits(s) {
+		return time.Time{}, fmt.Errorf("invalid start time '%s'", s)
+	}
+	year, _ := strconv.Atoi(s[:4])
+	doy, _ := strconv.Atoi(s[4:7])
+	hour, _ := strconv.Atoi(s[7:9])
+	minute, _ := strconv.Atoi(s[9:11])
+	if doy < 1 || doy > 366 || hour > 23 || minute > 59 {
+		return time.Time{}, fmt.Errorf("invalid start time '%s'", s)
+	}
+	return time.Date(year, 1, doy, hour, minute, 0, 0, time.UTC), nil
+}
+
+// units of the period and the frequency of the long name. Frequencies of 1 Hz
+// and 100 Hz, "Z" and "C", are handled in parseFileDuration.
+var fileDurationUnits = []struct {
+	unit byte
+	d    time.Duration
+}{
+	{'S', time.Second},
+	{'M', time.Minute},
+	{'H', time.Hour},
+	{'D', 24 * time.Hour},
+	{'Y', 365 * 24 * time.Hour},
+}
+
+// parseFileDuration parses the period or the frequency of the long name,
+// e.g. "01H", "30S" or "10Z". "00U" (unspecified) returns 0.
+//
+// The frequencies are given in Hz ("Z") or in 100 Hz ("C"), as in the table of
+// the file name fields of RINEX 3.04, section 4 ("XXC – 100 Hertz").
+func parseFileDuration(s string) (time.Duration, error) {
+	if len(s) != 3 || !isDigits(s[:2]) {
+		return 0, fmt.Errorf("invalid duration '%s'", s)
+	}
+	n, _ := strconv.Atoi(s[:2])
+
+	switch s[2] {
+	case 'U':
+		return 0, nil
+	case 'Z', 'C':
+		if n == 0 {
+			return 0, fmt.Errorf("invalid duration '%s'", s)
+		}
+		if s[2] == 'C' {
+			return 10 * time.Millisecond / time.Duration(n), nil
+		}
+		return time.Second / time.Duration(n), nil
+	}
+	for _, u := range fileDurationUnits {
+		if u.unit == s[2] {
+			return time.Duration(n) * u.d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid duration '%s'", s)
+}
+
+// formatFileDuration returns the period or the frequency of the long name,
+// e.g. "01H", "30S", "10Z" or "01C". 0 is "00U".
+func formatFileDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "00U"
+	case d < time.Second && time.Second%d == 0 && time.Second/d < 100:
+		return fmt.Sprintf("%02dZ", time.Second/d)
+	case d < time.Second && (10*time.Millisecond)%d == 0 && (10*time.Millisecond)/d < 100:
+		return fmt.Sprintf("%02dC", (10*time.Millisecond)/d)
+	case d < time.Second:
+		return "00U"
+	}
+
+	// the largest unit representing d in 2 digits
+	for i := len(fileDurationUnits) - 1; i >= 0; i-- {
+		u := fileDurationUnits[i]
+		if d%u.d == 0 && d/u.d < 100 {
+			return fmt.Sprintf("%02d%c", d/u.d, u.unit)
+		}
+	}
+	for _, u := range fileDurationUnits {
+		if n := (d + u.d/2) / u.d; n < 100 {
+			return fmt.Sprintf("%02d%c", n, u.unit)
+		}
+	}
+	return "00U"
+}
+
+// isDigits reports whether s consists of decimal digits only.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
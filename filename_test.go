@@ -0,0 +1,119 @@
+package crinex
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseFileName(t *testing.T) {
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		want FileName
+	}{
+		{"ABCD00JPN_R_20230010000_01D_30S_MO.crx.gz", FileName{Long: true, Station: "ABCD", Country: "JPN", Source: "R",
+			Start: day, Period: 24 * time.Hour, Interval: 30 * time.Second, Content: "MO", Format: "crx", Compress: "gz"}},
+		{"ABCD12JPN_S_20230011015_15M_10Z_GO.rnx", FileName{Long: true, Station: "ABCD", Monument: 1, Receiver: 2, Country: "JPN", Source: "S",
+			Start: day.Add(10*time.Hour + 15*time.Minute), Period: 15 * time.Minute, Interval: 100 * time.Millisecond, Content: "GO", Format: "rnx"}},
+		{"ABCD00JPN_R_20230010000_01H_01C_MO.crx", FileName{Long: true, Station: "ABCD", Country: "JPN", Source: "R",
+			Start: day, Period: time.Hour, Interval: 10 * time.Millisecond, Content: "MO", Format: "crx"}},
+		{"ABCD00JPN_R_20230010000_01H_02C_MO.crx", FileName{Long: true, Station: "ABCD", Country: "JPN", Source: "R",
+			Start: day, Period: time.Hour, Interval: 5 * time.Millisecond, Content: "MO", Format: "crx"}},
+		{"ABCD00JPN_R_20230010000_01D_GN.rnx", FileName{Long: true, Station: "ABCD", Country: "JPN", Source: "R",
+			Start: day, Period: 24 * time.Hour, Content: "GN", Format: "rnx"}},
+		{"abcd0010.23d", FileName{Station: "abcd", Start: day, Period: 24 * time.Hour, Content: "MO", Format: "crx"}},
+		{"abcd001k30.23o.Z", FileName{Station: "abcd", Start: day.Add(10*time.Hour + 30*time.Minute), Period: 15 * time.Minute,
+			Content: "MO", Format: "rnx", Compress: "Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFileName(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f != tt.want {
+				t.Errorf("ParseFileName = %+v, want %+v", f, tt.want)
+			}
+			if s := f.String(); s != tt.name {
+				t.Errorf("String() = %s", s)
+			}
+		})
+	}
+}
+
+func TestParseFileNameInvalid(t *testing.T) {
+	for _, name := range []string{
+		"ABCD00JPN_R_20230010000_01D_00Z_MO.crx", // 0 Hz
+		"ABCD00JPN_R_20230010000_01D_00C_MO.crx",
+		"ABCD00JPN_R_20230010000_01X_30S_MO.crx", // unknown unit
+		"ABCD00JPN_R_20233670000_01D_30S_MO.crx", // day of year
+		"ABCD00JPN_R_20230010000_01D_30S_MO",     // no extension
+		"abcd0010.23x",
+		"abcd001y.23d", // session
+	} {
+		if _, err := ParseFileName(name); !errors.Is(err, ErrInvalidFileName) {
+			t.Errorf("%s: err = %v, want ErrInvalidFileName", name, err)
+		}
+	}
+}
+
+func TestFormatFileDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "00U"},
+		{30 * time.Second, "30S"},
+		{15 * time.Minute, "15M"},
+		{time.Hour, "01H"},
+		{90 * time.Minute, "90M"},
+		{24 * time.Hour, "01D"},
+		{100 * time.Millisecond, "10Z"},
+		{20 * time.Millisecond, "50Z"},
+		{10 * time.Millisecond, "01C"},
+		{5 * time.Millisecond, "02C"},
+		{300 * time.Millisecond, "00U"},
+	}
+	for _, tt := range tests {
+		if got := formatFileDuration(tt.d); got != tt.want {
+			t.Errorf("formatFileDuration(%v) = %s, want %s", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestNewFileName(t *testing.T) {
+	b, err := os.ReadFile("testdata/example_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewScanner(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ParseHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFileName(s.Header(), time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Hour)
+	f.Country = "JPN"
+	if got, want := f.LongName(), "TEST00JPN_R_20230010000_01H_30S_MO.rnx"; got != want {
+		t.Errorf("LongName() = %s, want %s", got, want)
+	}
+	if got, want := f.ShortName(), "test001a.23o"; got != want {
+		t.Errorf("ShortName() = %s, want %s", got, want)
+	}
+	if diags := f.Check(s.Header()); len(diags) != 0 {
+		t.Errorf("Check() = %+v", diags)
+	}
+
+	// file of the next hour
+	f.Start = f.Start.Add(time.Hour)
+	if diags := f.Check(s.Header()); len(diags) != 1 || diags[0].Code != CodeFileNameStart {
+		t.Errorf("Check() = %+v, want %s", diags, CodeFileNameStart)
+	}
+}
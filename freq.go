@@ -0,0 +1,114 @@
+package crinex
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------
+// Carrier frequencies
+// ---------------------------------------------------
+
+// SpeedOfLight is the speed of light in vacuum in m/s.
+const SpeedOfLight = 299792458.0
+
+// carrierFreqs is the carrier frequencies in Hz of the frequency bands
+// (the second character of the observation code) of the satellite systems.
+// GLONASS FDMA bands are given by glonassFreqs.
+var carrierFreqs = map[string]map[byte]float64{
+	"G": {'1': 1575.42e6, '2': 1227.60e6, '5': 1176.45e6},
+	"R": {'3': 1202.025e6, '4': 1600.995e6, '6': 1248.06e6},
+	"E": {'1': 1575.42e6, '5': 1176.45e6, '7': 1207.14e6, '8': 1191.795e6, '6': 1278.75e6},
+	"C": {'1': 1575.42e6, '2': 1561.098e6, '5': 1176.45e6, '7': 1207.14e6, '8': 1191.795e6, '6': 1268.52e6},
+	"J": {'1': 1575.42e6, '2': 1227.60e6, '5': 1176.45e6, '6': 1278.75e6},
+	"I": {'5': 1176.45e6, '9': 2492.028e6},
+	"S": {'1': 1575.42e6, '5': 1176.45e6},
+}
+
+// glonassFreqs is the base frequency and the channel spacing in Hz of the
+// GLONASS FDMA bands.
+var glonassFreqs = map[byte][2]float64{
+	'1': {1602e6, 0.5625e6},
+	'2': {1246e6, 0.4375e6},
+}
+
+// FreqTable gives the carrier frequencies of the observation codes. The
+// GLONASS FDMA frequencies depend on the frequency channels of the
+// satellites, given by the GLONASS SLOT / FRQ # header.
+type FreqTable struct {
+	glonass map[string]int // frequency channel of the GLONASS slots, e.g. "R01"
+}
+
+// NewFreqTable returns the FreqTable for the RINEX header, e.g.
+// Scanner.Header().
+func NewFreqTable(header []byte) FreqTable {
+	f := FreqTable{glonass: make(map[string]int)}
+	for _, l := range headerLines(header) {
+		if headerLabel(l) != labelGlonassSlot {
+			continue
+		}
+
+		// 4X (or the number of satellites), then 8(A1,I2.2,1X,I2,1X)
+		c := headerContent(l)
+		for i := 4; i+6 <= len(c); i += 7 {
+			sat := strings.TrimSpace(c[i : i+3])
+			ch, err := strconv.Atoi(strings.TrimSpace(c[i+4 : i+6]))
+			if len(sat) == 3 && sat[0] == 'R' && err == nil {
+				f.glonass[sat] = ch
+			}
+		}
+	}
+	return f
+}
+
+// GlonassChannel returns the frequency channel of the GLONASS satellite, e.g.
+// "R01".
+func (f FreqTable) GlonassChannel(sat string) (ch int, ok bool) {
+	ch, ok = f.glonass[sat]
+	return ch, ok
+}
+
+// SetGlonassChannel sets the frequency channel of the GLONASS satellite, for
+// files without the GLONASS SLOT / FRQ # header.
+func (f *FreqTable) SetGlonassChannel(sat string, ch int) {
+	if f.glonass == nil {
+		f.glonass = make(map[string]int)
+	}
+	f.glonass[sat] = ch
+}
+
+// Frequency returns the carrier frequency in Hz of the observation code, e.g.
+// "L1C" (RINEX 3) or "L1" (RINEX 2), for the satellite. ok is false if the
+// band is unknown, or the frequency channel of a GLONASS FDMA satellite is
+// not known. Satellites without the system identifier (RINEX 2) are GPS.
+func (f FreqTable) Frequency(sat, code string) (freq float64, ok bool) {
+	if len(sat) == 0 || len(code) < 2 {
+		return 0, false
+	}
+	sys := sat[:1]
+	if sys == " " {
+		sys = "G"
+	}
+	band := code[1]
+
+	if fdma, found := glonassFreqs[band]; sys == "R" && found {
+		ch, found := f.glonass[sat]
+		if !found {
+			return 0, false
+		}
+		return fdma[0] + float64(ch)*fdma[1], true
+	}
+
+	freq, ok = carrierFreqs[sys][band]
+	return freq, ok
+}
+
+// Wavelength returns the carrier wavelength in meters of the observation
+// code for the satellite. See Frequency.
+func (f FreqTable) Wavelength(sat, code string) (lambda float64, ok bool) {
+	freq, ok := f.Frequency(sat, code)
+	if !ok {
+		return 0, false
+	}
+	return SpeedOfLight / freq, true
+}
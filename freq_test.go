@@ -0,0 +1,62 @@
+package crinex
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFreqTable(t *testing.T) {
+	header := joinHeaderLines([]string{
+		formatHeaderLine("     3.04           OBSERVATION DATA    M", "RINEX VERSION / TYPE"),
+		formatHeaderLine("  9 R01  1 R02 -4 R03  5 R04  6 R05  1 R06 -4 R07  5 R08  6", labelGlonassSlot),
+		formatHeaderLine("    R09 -2", labelGlonassSlot),
+	})
+	f := NewFreqTable(header)
+
+	// the channels of both lines, including the negative ones
+	for sat, want := range map[string]int{"R01": 1, "R02": -4, "R08": 6, "R09": -2} {
+		if ch, ok := f.GlonassChannel(sat); !ok || ch != want {
+			t.Errorf("GlonassChannel(%s) = %d %v, want %d", sat, ch, ok, want)
+		}
+	}
+	if ch, ok := f.GlonassChannel("R10"); ok {
+		t.Errorf("GlonassChannel(R10) = %d, want not found", ch)
+	}
+
+	tests := []struct {
+		sat, code string
+		freq      float64 // 0 if not known
+	}{
+		{"G01", "L1C", 1575.42e6},
+		{" 01", "L2", 1227.60e6},
+		{"G01", "L5Q", 1176.45e6},
+		{"R01", "L1C", 1602e6 + 0.5625e6},
+		{"R02", "L1C", 1602e6 - 4*0.5625e6},
+		{"R02", "L2P", 1246e6 - 4*0.4375e6},
+		{"R09", "C2C", 1246e6 - 2*0.4375e6},
+		{"R10", "L1C", 0},
+		{"R10", "L3Q", 1202.025e6},
+		{"E01", "L8Q", 1191.795e6},
+		{"C01", "L2I", 1561.098e6},
+		{"G01", "L7Q", 0},
+		{"G01", "L", 0},
+		{"", "L1C", 0},
+	}
+	for _, tt := range tests {
+		freq, ok := f.Frequency(tt.sat, tt.code)
+		if ok != (tt.freq != 0) || freq != tt.freq {
+			t.Errorf("Frequency(%q, %q) = %g %v, want %g", tt.sat, tt.code, freq, ok, tt.freq)
+		}
+		lambda, ok := f.Wavelength(tt.sat, tt.code)
+		if tt.freq != 0 && (!ok || math.Abs(lambda-SpeedOfLight/tt.freq) > 1e-12) {
+			t.Errorf("Wavelength(%q, %q) = %g %v", tt.sat, tt.code, lambda, ok)
+		}
+	}
+
+	// the channel of a satellite not in the header
+	var g FreqTable
+	g.SetGlonassChannel("R10", -7)
+	if freq, ok := g.Frequency("R10", "L1C"); !ok || freq != 1602e6-7*0.5625e6 {
+		t.Errorf("Frequency of R10 with channel -7 = %g %v", freq, ok)
+	}
+}
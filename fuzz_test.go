@@ -0,0 +1,149 @@
+package crinex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds returns the example files in testdata and malformed variants of
+// them as the seed corpus.
+func fuzzSeeds(f *testing.F) {
+	var concat []byte
+	for _, name := range []string{"testdata/example_v1.crx", "testdata/example_v3.crx"} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+		concat = append(concat, b...)
+
+		// truncated, CRLF and header-only variants
+		f.Add(b[:len(b)/2])
+		f.Add(bytes.ReplaceAll(b, []byte("\n"), []byte("\r\n")))
+		if i := bytes.Index(b, []byte("END OF HEADER")); i > 0 {
+			f.Add(b[:i])
+		}
+	}
+
+	// concatenated files
+	f.Add(concat)
+
+	// broken epoch records repaired by the built-in RepairRules
+	v1 := "1.0                 COMPACT RINEX FORMAT                    CRINEX VERS   / TYPE\n" +
+		"RNX2CRX ver.4.1.0                       18-Oct-26 00:00     CRINEX PROG / DATE\n" +
+		"     2.11           OBSERVATION DATA    G (GPS)             RINEX VERSION / TYPE\n" +
+		"     1    C1                                                # / TYPES OF OBSERV\n" +
+		"                                                            END OF HEADER\n"
+	f.Add([]byte(v1 + "&99  6 12  0 14  0.0000000  0  8 18 14 27 16 4 19 22 15\n\n" + "3&1\n3&1\n3&1\n3&1\n3&1\n3&1\n3&1\n3&1\n"))
+	f.Add([]byte(v1 + "&99  8 13  0 20 30.0000000  0  8  07 27 18 04 10 02 19 13\n\n" + "3&1\n3&1\n3&1\n3&1\n3&1\n3&1\n3&1\n3&1\n"))
+	f.Add([]byte(v1 + "&98  9  9  0  0  0.0000000  0  2  4G9 \n\n" + "3&1\n3&1\n"))
+	f.Add([]byte(v1 + "&98  9  9  0  0  0.0000000  4  1\n" + "event\n"))
+}
+
+func FuzzNewScanner(f *testing.F) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		s, err := NewScanner(bytes.NewReader(b))
+		if err != nil {
+			return
+		}
+		s.ParseHeader()
+		for s.ScanEpoch() {
+			s.EpochAsBytes()
+			s.DataAsBytes()
+			s.Data()
+			s.ClockOffset()
+			s.PicoSeconds()
+		}
+	})
+}
+
+func FuzzNewReader(f *testing.F) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		r, err := NewReader(bytes.NewReader(b))
+		if r != nil {
+			io.Copy(io.Discard, r)
+		}
+		_ = err
+	})
+}
+
+func FuzzDiffRecordDecode(f *testing.F) {
+	for _, s := range []string{"3&20000000000", "1000", "-5255", "", "&", "9&1", "0&1", "x&1", "3&", "99999999999999999999"} {
+		f.Add([]byte("3&100"), []byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, init, b []byte) {
+		var r diffRecord
+		r.Decode(init)
+		for i := 0; i < 5; i++ {
+			r.Decode(b)
+		}
+	})
+}
+
+func TestMalformedInput(t *testing.T) {
+	const (
+		magic = "1.0                 COMPACT RINEX FORMAT                    CRINEX VERS   / TYPE\n" +
+			"RNX2CRX ver.4.1.0                       18-Oct-26 00:00     CRINEX PROG / DATE\n"
+		version  = "     2.11           OBSERVATION DATA    G (GPS)             RINEX VERSION / TYPE\n"
+		obsTypes = "     1    C1                                                # / TYPES OF OBSERV\n"
+		end      = "                                                            END OF HEADER\n"
+	)
+
+	tests := []struct {
+		name      string
+		in        string
+		epochs    int
+		scanErr   error // error of the Scanner
+		readerErr error // error of the Reader
+		code      Code  // diagnostic of the Scanner
+	}{
+		{"no-version", magic + strings.Replace(version, "2.11", "    ", 1) + obsTypes + end,
+			0, ErrInvalidHeader, ErrInvalidHeader, ""},
+		{"empty-obstypes", magic + version + strings.Replace(obsTypes, "1    C1", "       ", 1) + end,
+			0, nil, nil, CodeInvalidObsTypes},
+		{"short-epoch", magic + version + obsTypes + end + "&99  6 12  0 14  0.00000\n\n",
+			0, ErrInvalidEpochStr, ErrInvalidSatList, CodeInvalidEpoch},
+		{"numsat", magic + version + obsTypes + end + "&99  6 12  0 14  0.0000000  0 13G01\n\n3&1\n",
+			1, nil, nil, CodeNumSatMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScanner(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			epochs := 0
+			for s.ScanEpoch() {
+				s.EpochAsBytes()
+				s.DataAsBytes()
+				epochs++
+			}
+			if epochs != tt.epochs {
+				t.Errorf("epochs = %d, want %d", epochs, tt.epochs)
+			}
+			if err := s.Err(); !errors.Is(err, tt.scanErr) {
+				t.Errorf("Scanner: err = %v, want %v", err, tt.scanErr)
+			}
+			if tt.code != "" && !slices.ContainsFunc(s.Diagnostics, func(d Diagnostic) bool { return d.Code == tt.code }) {
+				t.Errorf("Scanner: %s not reported: %v", tt.code, s.Diagnostics)
+			}
+
+			r, err := NewReader(strings.NewReader(tt.in))
+			if !errors.Is(err, tt.readerErr) {
+				t.Errorf("Reader: err = %v, want %v", err, tt.readerErr)
+			}
+			io.Copy(io.Discard, r)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package crinex
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ---------------------------------------------------
+// Epoch time with pico-second resolution
+// ---------------------------------------------------
+
+// GNSSTime is a time tag with pico-second resolution: integer seconds and
+// the fractional part in picoseconds. The RINEX epoch (0.1 microsecond
+// resolution) combined with the pico-second record of RINEX>=4.02
+// (CRINEX>=3.1) is represented exactly.
+//
+// Like Scanner.Epoch, the calendar fields are those of the time system of
+// the file. The zero value corresponds to the zero value of time.Time.
+type GNSSTime struct {
+	sec int64 // seconds since January 1, year 1 00:00:00
+	ps  int64 // picoseconds in [0, 1e12)
+}
+
+// Picoseconds is the difference of two GNSSTimes in picoseconds. The range is
+// about ±106 days.
+type Picoseconds int64
+
+const (
+	picoPerSec  = 1000000000000
+	picoPerNano = 1000
+
+	// seconds from January 1, year 1 to January 1, 1970
+	unixToGNSSTime int64 = 62135596800
+)
+
+// NewGNSSTime returns the GNSSTime of t plus ps picoseconds.
+func NewGNSSTime(t time.Time, ps int64) GNSSTime {
+	return GNSSTime{sec: t.Unix() + unixToGNSSTime}.addPico(int64(t.Nanosecond())*picoPerNano + ps)
+}
+
+// addPico returns t+ps, keeping the picoseconds in [0, 1e12).
+func (t GNSSTime) addPico(ps int64) GNSSTime {
+	t.sec += ps / picoPerSec
+	t.ps += ps % picoPerSec
+	switch {
+	case t.ps >= picoPerSec:
+		t.sec++
+		t.ps -= picoPerSec
+	case t.ps < 0:
+		t.sec--
+		t.ps += picoPerSec
+	}
+	return t
+}
+
+// Time returns t as time.Time, truncated to nanoseconds.
+func (t GNSSTime) Time() time.Time {
+	return time.Unix(t.sec-unixToGNSSTime, t.ps/picoPerNano).UTC()
+}
+
+// Picosecond returns the fractional part of the second in picoseconds, in
+// the range [0, 1e12).
+func (t GNSSTime) Picosecond() int64 {
+	return t.ps
+}
+
+// IsZero reports whether t is the zero time.
+func (t GNSSTime) IsZero() bool {
+	return t.sec == 0 && t.ps == 0
+}
+
+// Add returns t+d.
+func (t GNSSTime) Add(d Picoseconds) GNSSTime {
+	return t.addPico(int64(d))
+}
+
+// AddDuration returns t+d.
+func (t GNSSTime) AddDuration(d time.Duration) GNSSTime {
+	t.sec += int64(d / time.Second)
+	return t.addPico(int64(d%time.Second) * picoPerNano)
+}
+
+// Sub returns t-u. The result is saturated to the minimum or maximum
+// Picoseconds on overflow.
+func (t GNSSTime) Sub(u GNSSTime) Picoseconds {
+	dsec := t.sec - u.sec
+	dps := t.ps - u.ps
+	if dps < 0 {
+		dsec--
+		dps += picoPerSec
+	}
+	if dsec > (math.MaxInt64-dps)/picoPerSec {
+		return math.MaxInt64
+	}
+	if dsec < math.MinInt64/picoPerSec {
+		return math.MinInt64
+	}
+	return Picoseconds(dsec*picoPerSec + dps)
+}
+
+// Compare returns -1 if t is before u, +1 if t is after u, or 0 if equal.
+func (t GNSSTime) Compare(u GNSSTime) int {
+	switch {
+	case t.sec < u.sec || (t.sec == u.sec && t.ps < u.ps):
+		return -1
+	case t.sec > u.sec || (t.sec == u.sec && t.ps > u.ps):
+		return 1
+	}
+	return 0
+}
+
+// Before reports whether t is before u.
+func (t GNSSTime) Before(u GNSSTime) bool { return t.Compare(u) < 0 }
+
+// After reports whether t is after u.
+func (t GNSSTime) After(u GNSSTime) bool { return t.Compare(u) > 0 }
+
+// Equal reports whether t and u are the same time.
+func (t GNSSTime) Equal(u GNSSTime) bool { return t == u }
+
+// String returns t formatted as "2006-01-02 15:04:05.000000000000", with 12
+// digits of the fractional second.
+func (t GNSSTime) String() string {
+	return fmt.Sprintf("%s.%012d", time.Unix(t.sec-unixToGNSSTime, 0).UTC().Format(time.DateTime), t.ps)
+}
+
+// Duration returns d as time.Duration, truncated to nanoseconds.
+func (d Picoseconds) Duration() time.Duration {
+	return time.Duration(d / picoPerNano)
+}
+
+// Seconds returns d in seconds.
+func (d Picoseconds) Seconds() float64 {
+	sec := d / picoPerSec
+	return float64(sec) + float64(d%picoPerSec)/picoPerSec
+}
+
+// String returns d in seconds with 12 digits of the fractional second, e.g.
+// "0.100000000001s".
+func (d Picoseconds) String() string {
+	sign := ""
+	u := uint64(d)
+	if d < 0 {
+		sign, u = "-", -u
+	}
+	return fmt.Sprintf("%s%d.%012ds", sign, u/picoPerSec, u%picoPerSec)
+}
+
+// EpochTime returns the time tag of the current epoch combined with the
+// pico-second record, if any.
+func (s *Scanner) EpochTime() GNSSTime {
+	ps := s.PicoSeconds()
+	if ps < 0 {
+		// missing
+		ps = 0
+	}
+	return NewGNSSTime(s.epoch, int64(ps))
+}
@@ -0,0 +1,117 @@
+package crinex
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGNSSTime(t *testing.T) {
+	t0 := NewGNSSTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 12345)
+	if got, want := t0.String(), "2023-01-01 00:00:00.000000012345"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got := t0.Picosecond(); got != 12345 {
+		t.Errorf("Picosecond() = %d, want 12345", got)
+	}
+	if got, want := t0.Time(), time.Date(2023, 1, 1, 0, 0, 0, 12, time.UTC); !got.Equal(want) {
+		t.Errorf("Time() = %v, want %v", got, want)
+	}
+
+	// across the second
+	t1 := t0.Add(-12346)
+	if got, want := t1.String(), "2022-12-31 23:59:59.999999999999"; got != want {
+		t.Errorf("Add(-12346) = %s, want %s", got, want)
+	}
+	if d := t1.Sub(t0); d != -12346 || d.String() != "-0.000000012346s" {
+		t.Errorf("Sub() = %d (%s), want -12346", d, d)
+	}
+	if !t1.Before(t0) || !t0.After(t1) || t0.Compare(t1) != 1 || t1.Compare(t0) != -1 || t0.Compare(t0) != 0 {
+		t.Errorf("Compare(%s, %s) is inconsistent", t0, t1)
+	}
+
+	t2 := t0.AddDuration(30*time.Second + time.Nanosecond)
+	if got, want := t2.String(), "2023-01-01 00:00:30.000000013345"; got != want {
+		t.Errorf("AddDuration() = %s, want %s", got, want)
+	}
+	if d := t2.Sub(t0); d.Duration() != 30*time.Second+time.Nanosecond || d.Seconds() != 30.000000001 {
+		t.Errorf("Sub() = %s", d)
+	}
+	if !t2.Add(t0.Sub(t2)).Equal(t0) {
+		t.Errorf("t2 + (t0 - t2) != t0")
+	}
+
+	// saturated
+	if d := t0.Sub(GNSSTime{}); d != math.MaxInt64 {
+		t.Errorf("Sub(zero) = %d, want MaxInt64", d)
+	}
+	if d := (GNSSTime{}).Sub(t0); d != math.MinInt64 {
+		t.Errorf("zero.Sub() = %d, want MinInt64", d)
+	}
+	if !(GNSSTime{}).IsZero() || t0.IsZero() || !NewGNSSTime(time.Time{}, 0).IsZero() {
+		t.Errorf("IsZero() is inconsistent")
+	}
+}
+
+func TestEpochTime(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"testdata/picosec_v31.crx", []string{
+			"2023-01-01 00:00:00.000000012345",
+			"2023-01-01 00:00:30.000000000000", // the pico-second record is removed
+			"2023-01-01 00:01:30.000000054321",
+		}},
+		{"testdata/example_v3.crx", []string{
+			"2023-01-01 00:00:00.000000000000",
+			"2023-01-01 00:00:30.000000000000",
+			"2023-01-01 00:01:30.000000000000",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			s, err := NewScanner(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for s.ScanEpoch() {
+				got = append(got, s.EpochTime().String())
+			}
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("EpochTime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderPicoSec(t *testing.T) {
+	want := []string{
+		"> 2023 01 01 00 00  0.0000000  0  3                      12345",
+		"> 2023 01 01 00 00 30.0000000  0  3",
+		"> 2023 01 01 00 01 30.0000000  0  3                      54321",
+	}
+
+	var got []string
+	for _, l := range bytes.Split(readerOutput(t, "testdata/picosec_v31.crx"), []byte("\n")) {
+		if bytes.HasPrefix(l, []byte(">")) {
+			got = append(got, string(l))
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("epoch records = %q, want %q", got, want)
+	}
+}
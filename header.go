@@ -0,0 +1,211 @@
+package crinex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------
+// RINEX header utilities
+// ---------------------------------------------------
+
+// header labels
+const (
+	labelRinexVersion       = "RINEX VERSION / TYPE"
+	labelObsTypesV3         = "SYS / # / OBS TYPES"
+	labelObsTypesV2         = "# / TYPES OF OBSERV"
+	labelFirstObs           = "TIME OF FIRST OBS"
+	labelLastObs            = "TIME OF LAST OBS"
+	labelLeapSeconds        = "LEAP SECONDS"
+	labelSignalStrengthUnit = "SIGNAL STRENGTH UNIT"
+	labelGlonassSlot        = "GLONASS SLOT / FRQ #"
+	labelEndOfHeader        = "END OF HEADER"
+)
+
+// headerLines splits the header bytes into lines.
+func headerLines(h []byte) []string {
+	return strings.Split(strings.TrimRight(string(h), "\n"), "\n")
+}
+
+// joinHeaderLines joins the header lines into the header bytes.
+func joinHeaderLines(lines []string) []byte {
+	var b []byte
+	for _, l := range lines {
+		b = append(b, l...)
+		b = append(b, '\n')
+	}
+	return b
+}
+
+// headerLabel returns the label of the header line, e.g. "END OF HEADER".
+func headerLabel(line string) string {
+	if len(line) <= 60 {
+		return ""
+	}
+	return strings.TrimSpace(line[60:])
+}
+
+// headerContent returns the content of the header line, i.e. the first 60 bytes.
+func headerContent(line string) string {
+	if len(line) < 60 {
+		return line
+	}
+	return line[:60]
+}
+
+// formatHeaderLine returns a header line with the content and the label.
+func formatHeaderLine(content, label string) string {
+	return fmt.Sprintf("%-60.60s%s", content, label)
+}
+
+// findHeaderLine returns the first header line with the label.
+func findHeaderLine(lines []string, label string) (string, bool) {
+	for _, l := range lines {
+		if headerLabel(l) == label {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// replaceHeaderLines replaces all the header lines with the label by newLines.
+// newLines are placed at the position of the first line with the label, or
+// inserted before the line with the label "before" if the label is not found.
+// If neither is found, newLines are inserted before "END OF HEADER".
+func replaceHeaderLines(lines []string, label, before string, newLines []string) []string {
+	var (
+		r        []string
+		replaced bool
+	)
+
+	for _, l := range lines {
+		if headerLabel(l) == label {
+			if !replaced {
+				r = append(r, newLines...)
+				replaced = true
+			}
+			continue
+		}
+		r = append(r, l)
+	}
+	if replaced {
+		return r
+	}
+
+	// insert
+	for _, b := range []string{before, labelEndOfHeader} {
+		for i, l := range r {
+			if b != "" && headerLabel(l) == b {
+				return append(r[:i], append(newLines, r[i:]...)...)
+			}
+		}
+	}
+	return append(r, newLines...)
+}
+
+// obsTypesOrder returns the satellite systems in the order of the
+// "SYS / # / OBS TYPES" header lines.
+func obsTypesOrder(lines []string) (systems []string) {
+	for _, l := range lines {
+		if headerLabel(l) == labelObsTypesV3 && len(l) > 0 && l[0] != ' ' {
+			systems = append(systems, l[:1])
+		}
+	}
+	return
+}
+
+// formatObsTypesV3 returns "SYS / # / OBS TYPES" header lines for the
+// satellite systems in the order of systems.
+func formatObsTypesV3(obsTypes map[string][]string, systems []string) (lines []string) {
+	for _, sys := range systems {
+		codes := obsTypes[sys]
+		s := fmt.Sprintf("%-1.1s  %3d", sys, len(codes))
+		for i, c := range codes {
+			if i > 0 && i%13 == 0 {
+				// continuation line
+				lines = append(lines, formatHeaderLine(s, labelObsTypesV3))
+				s = "      "
+			}
+			s += fmt.Sprintf(" %-3.3s", c)
+		}
+		lines = append(lines, formatHeaderLine(s, labelObsTypesV3))
+	}
+	return
+}
+
+// formatObsTypesV2 returns "# / TYPES OF OBSERV" header lines.
+func formatObsTypesV2(codes []string) (lines []string) {
+	s := fmt.Sprintf("%6d", len(codes))
+	for i, c := range codes {
+		if i > 0 && i%9 == 0 {
+			// continuation line
+			lines = append(lines, formatHeaderLine(s, labelObsTypesV2))
+			s = "      "
+		}
+		s += fmt.Sprintf("    %-2.2s", c)
+	}
+	return append(lines, formatHeaderLine(s, labelObsTypesV2))
+}
+
+// parseObsTime parses the "TIME OF FIRST OBS" or "TIME OF LAST OBS" header
+// line, and returns the time and the time system (e.g. "GPS"). The time
+// system is empty if not given.
+func parseObsTime(line string) (t time.Time, sys string, err error) {
+	c := headerContent(line)
+	if len(c) < 43 {
+		return t, sys, fmt.Errorf("%w: too short time of obs: '%s'", ErrInvalidHeader, line)
+	}
+
+	var v [5]int
+	for i := range v {
+		if v[i], err = strconv.Atoi(strings.TrimSpace(c[6*i : 6*i+6])); err != nil {
+			return t, sys, fmt.Errorf("%w: time of obs: '%s'", ErrInvalidHeader, line)
+		}
+	}
+	sec, err := strconv.ParseFloat(strings.TrimSpace(c[30:43]), 64)
+	if err != nil {
+		return t, sys, fmt.Errorf("%w: time of obs: '%s'", ErrInvalidHeader, line)
+	}
+	if len(c) >= 51 {
+		sys = strings.TrimSpace(c[48:51])
+	}
+
+	ns := int(sec*1e9 + 0.5)
+	t = time.Date(v[0], time.Month(v[1]), v[2], v[3], v[4], 0, ns, time.UTC)
+	return t, sys, nil
+}
+
+// formatObsTime returns a "TIME OF FIRST OBS" or "TIME OF LAST OBS" header
+// line given by the label.
+func formatObsTime(t time.Time, sys, label string) string {
+	sec := float64(t.Second()) + float64(t.Nanosecond())*1e-9
+	c := fmt.Sprintf("%6d%6d%6d%6d%6d%13.7f     %-3.3s",
+		t.Year(), int(t.Month()), t.Day(), t.Hour(), t.Minute(), sec, sys)
+	return formatHeaderLine(c, label)
+}
+
+// rinexMajorVersion returns the major version of RINEX, e.g. '3', given by the
+// "RINEX VERSION / TYPE" header line.
+func rinexMajorVersion(lines []string) byte {
+	if l, ok := findHeaderLine(lines, labelRinexVersion); ok {
+		if v := trimHeaderValue(l, 0, 20); len(v) > 0 {
+			return v[0]
+		}
+	}
+	return 0
+}
+
+// trimHeaderValue returns the trimmed field line[i:j] of the header line, or
+// empty if the line is too short.
+func trimHeaderValue(line string, i, j int) string {
+	c := headerContent(line)
+	if len(c) < i {
+		return ""
+	}
+	if len(c) < j {
+		j = len(c)
+	}
+	return strings.TrimSpace(c[i:j])
+}
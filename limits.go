@@ -0,0 +1,39 @@
+package crinex
+
+import "fmt"
+
+// LimitError is returned when the input exceeds a limit configured in Options.
+type LimitError struct {
+	Limit string // name of the option, e.g. "MaxSatellites"
+	Value int64  // value found in the input
+	Max   int64  // configured limit
+	Line  int    // line number, 0 if unknown
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: line %d: %s: %d > %d", ErrLimitExceeded, e.Line, e.Limit, e.Value, e.Max)
+}
+
+// Unwrap returns ErrLimitExceeded.
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// checkLimit returns a *LimitError if v exceeds max. max <= 0 means no limit.
+func checkLimit(limit string, v, max int64, line int) error {
+	if max > 0 && v > max {
+		return &LimitError{Limit: limit, Value: v, Max: max, Line: line}
+	}
+	return nil
+}
+
+// checkObsTypes returns a *LimitError if the number of observation types for
+// any satellite system exceeds MaxObsTypes.
+func (o *Options) checkObsTypes(obsTypes map[string][]string, line int) error {
+	for _, codes := range obsTypes {
+		if err := checkLimit("MaxObsTypes", int64(len(codes)), int64(o.MaxObsTypes), line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package crinex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// decodeWithOptions decodes b with the Scanner and the Reader, and returns the
+// RINEX and the errors of both.
+func decodeWithOptions(t *testing.T, b []byte, opts Options) (scanOut []byte, scanErr error, readOut []byte, readErr error) {
+	t.Helper()
+
+	var out bytes.Buffer
+	s, scanErr := NewScannerWithOptions(bytes.NewReader(b), opts)
+	if scanErr == nil && s.ParseHeader() == nil {
+		out.Write(s.Header())
+		for s.ScanEpoch() {
+			out.Write(s.EventsAsBytes())
+			out.Write(s.EpochAsBytes())
+			out.Write(s.DataAsBytes())
+		}
+	}
+	if scanErr == nil {
+		scanErr = s.Err()
+	}
+
+	r, readErr := NewReaderWithOptions(bytes.NewReader(b), opts)
+	readOut, _ = io.ReadAll(r)
+	return out.Bytes(), scanErr, readOut, readErr
+}
+
+func TestLimits(t *testing.T) {
+	b, err := os.ReadFile("testdata/example_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		line int
+	}{
+		{"MaxLineLength", Options{MaxLineLength: 70}, 1},
+		{"MaxObsTypes", Options{MaxObsTypes: 3}, 9},
+		{"MaxSatellites", Options{MaxSatellites: 2}, 10},
+		{"MaxDiff", Options{MaxDiff: 2}, 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, scanErr, _, readErr := decodeWithOptions(t, b, tt.opts)
+			for _, err := range []error{scanErr, readErr} {
+				var le *LimitError
+				if !errors.As(err, &le) || !errors.Is(err, ErrLimitExceeded) {
+					t.Fatalf("err = %v, want *LimitError", err)
+				}
+				if le.Limit != tt.name || le.Line != tt.line {
+					t.Errorf("limit %s at line %d, want %s at line %d", le.Limit, le.Line, tt.name, tt.line)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxDecodedBytes(t *testing.T) {
+	b, err := os.ReadFile("testdata/example_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, _, _, _ := decodeWithOptions(t, b, Options{})
+
+	// the decoded RINEX is larger than the input
+	if len(full) <= len(b) {
+		t.Fatalf("decoded %d bytes from %d bytes", len(full), len(b))
+	}
+	header := bytes.Index(full, []byte("> 2023 01 01 00 00  0.0000000"))
+	third := bytes.Index(full, []byte("> 2023 01 01 00 01 30.0000000"))
+
+	tests := []struct {
+		name string
+		max  int
+		want []byte // output
+		line int    // line of the *LimitError, 0 if no error
+	}{
+		{"all", len(full), full, 0},
+		{"two-epochs", third, full[:third], 20},
+		{"one-byte-short", len(full) - 1, full[:third], 20},
+		{"header", header - 1, nil, 9},
+		{"input-size", len(b), full[:third], 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{MaxDecodedBytes: int64(tt.max)}
+			scanOut, scanErr, readOut, readErr := decodeWithOptions(t, b, opts)
+
+			for _, err := range []error{scanErr, readErr} {
+				var le *LimitError
+				switch {
+				case tt.line == 0 && err != nil:
+					t.Errorf("unexpected error: %v", err)
+				case tt.line == 0:
+				case !errors.As(err, &le):
+					t.Errorf("err = %v, want *LimitError", err)
+				case le.Limit != "MaxDecodedBytes" || le.Line != tt.line:
+					t.Errorf("limit %s at line %d, want MaxDecodedBytes at line %d", le.Limit, le.Line, tt.line)
+				}
+			}
+			if !bytes.Equal(scanOut, tt.want) {
+				t.Errorf("Scanner output:\n%s\nwant:\n%s", scanOut, tt.want)
+			}
+			if !bytes.Equal(readOut, tt.want) {
+				t.Errorf("Reader output:\n%s\nwant:\n%s", readOut, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package crinex
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestLineEndings(t *testing.T) {
+	for _, name := range []string{"example_v1.crx", "event_v3.crx", "picosec_v31.crx"} {
+		t.Run(name, func(t *testing.T) {
+			b, err := os.ReadFile("testdata/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantScan, _, wantRead, _ := decodeWithOptions(t, b, Options{})
+
+			tests := []struct {
+				name string
+				b    []byte
+				opts Options
+			}{
+				{"crlf", bytes.ReplaceAll(b, []byte("\n"), []byte("\r\n")), Options{}},
+				{"small-buffer", b, Options{BufferSize: 16}},
+			}
+			for _, tt := range tests {
+				scanOut, scanErr, readOut, readErr := decodeWithOptions(t, tt.b, tt.opts)
+				if scanErr != nil || readErr != nil {
+					t.Fatalf("%s: %v, %v", tt.name, scanErr, readErr)
+				}
+				if !bytes.Equal(scanOut, wantScan) {
+					t.Errorf("%s: Scanner output:\n%q\nwant:\n%q", tt.name, scanOut, wantScan)
+				}
+				if !bytes.Equal(readOut, wantRead) {
+					t.Errorf("%s: Reader output:\n%q\nwant:\n%q", tt.name, readOut, wantRead)
+				}
+			}
+		})
+	}
+}
+
+func TestLongLine(t *testing.T) {
+	// a comment longer than the default buffer of bufio.Scanner
+	comment := bytes.Repeat([]byte("x"), 100*1024)
+	b := corrupted(t, "testdata/example_v3.crx", "TEST                                                        MARKER NAME\n",
+		"TEST                                                        MARKER NAME\n"+string(comment)+"COMMENT\n")
+
+	scanOut, scanErr, readOut, readErr := decodeWithOptions(t, b, Options{})
+	if scanErr != nil || readErr != nil {
+		t.Fatalf("%v, %v", scanErr, readErr)
+	}
+	if !bytes.Contains(scanOut, comment) || !bytes.Equal(scanOut, readOut) {
+		t.Errorf("long line not decoded")
+	}
+}
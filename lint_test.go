@@ -0,0 +1,46 @@
+package crinex
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestEpochChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		old, new string
+		code     Code
+		pos      []int // lines of the diagnostics
+	}{
+		{"duplicate", "testdata/example_v3.crx", "\n                   3\n", "\n\n", CodeDuplicateEpoch, []int{15}},
+		{"picosec", "testdata/picosec_v31.crx", "\n 12345\n", "\n 12a45\n", CodeInvalidPicoSec, []int{11}},
+		{"overflow", "testdata/example_v3.crx", " 3&117000000000 ", " 3&117000000000000 ", CodeValueOverflow, []int{14, 19, 24}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScanner(bytes.NewReader(corrupted(t, tt.file, tt.old, tt.new)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for s.ScanEpoch() {
+				s.DataAsBytes()
+			}
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+			var pos []int
+			for _, w := range s.Diagnostics {
+				if w.Code != tt.code {
+					t.Errorf("unexpected diagnostic: %v", w)
+				}
+				pos = append(pos, w.Pos)
+			}
+			if !slices.Equal(pos, tt.pos) {
+				t.Errorf("%s at lines %v, want %v", tt.code, pos, tt.pos)
+			}
+		})
+	}
+}
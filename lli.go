@@ -0,0 +1,80 @@
+package crinex
+
+import (
+	"math"
+	"strings"
+)
+
+// ---------------------------------------------------
+// Loss of lock indicator and signal strength
+// ---------------------------------------------------
+
+// LLI is the loss of lock indicator of an observation, a set of the bits
+// below.
+type LLI uint8
+
+const (
+	LLILostLock  LLI = 1 << iota // bit 0: lost lock between the previous and the current observation, cycle slip possible
+	LLIHalfCycle                 // bit 1: half-cycle ambiguity (opposite wavelength factor in RINEX 2)
+	LLIBOC                       // bit 2: Galileo BOC-tracking of an MBOC modulated signal
+)
+
+// LostLock reports whether bit 0 is set.
+func (l LLI) LostLock() bool { return l&LLILostLock != 0 }
+
+// HalfCycle reports whether bit 1 is set.
+func (l LLI) HalfCycle() bool { return l&LLIHalfCycle != 0 }
+
+// BOC reports whether bit 2 is set.
+func (l LLI) BOC() bool { return l&LLIBOC != 0 }
+
+// LossOfLock returns the loss of lock indicator. ok is false if the indicator
+// is blank or not a digit 0-7.
+func (d *SatObsData) LossOfLock() (l LLI, ok bool) {
+	if d.LLI < '0' || d.LLI > '7' {
+		return 0, false
+	}
+	return LLI(d.LLI - '0'), true
+}
+
+// SignalStrength is the signal strength indicator of an observation: 1
+// (minimum) to 9 (maximum), or 0 if not known.
+type SignalStrength uint8
+
+// SignalStrength returns the signal strength indicator, 0 if blank or not a
+// digit.
+func (d *SatObsData) SignalStrength() SignalStrength {
+	if d.SS < '0' || d.SS > '9' {
+		return 0
+	}
+	return SignalStrength(d.SS - '0')
+}
+
+// CN0 returns the range [lo, hi) of the carrier to noise density in dB-Hz
+// represented by the indicator, e.g. [30, 36) for 5, given the unit of SIGNAL
+// STRENGTH UNIT. ok is false if the indicator is unknown, or the unit is not
+// "DBHZ": the mapping is not defined in RINEX.
+func (ss SignalStrength) CN0(unit string) (lo, hi float64, ok bool) {
+	if ss < 1 || ss > 9 || !strings.EqualFold(strings.TrimSpace(unit), "DBHZ") {
+		return 0, 0, false
+	}
+
+	// 1: < 12, 2: 12-17, ..., 8: 48-53, 9: >= 54
+	lo, hi = float64(6*ss), float64(6*ss+6)
+	if ss == 1 {
+		lo = 0
+	}
+	if ss == 9 {
+		hi = math.Inf(1)
+	}
+	return lo, hi, true
+}
+
+// SignalStrengthUnit returns the unit of the signal strength given by the
+// SIGNAL STRENGTH UNIT header, e.g. "DBHZ", or empty if not given.
+func (s *Scanner) SignalStrengthUnit() string {
+	if l, ok := findHeaderLine(headerLines(s.header), labelSignalStrengthUnit); ok {
+		return trimHeaderValue(l, 0, 20)
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package crinex
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestLossOfLock(t *testing.T) {
+	tests := []struct {
+		lli                      byte
+		want                     LLI
+		ok                       bool
+		lostLock, halfCycle, boc bool
+	}{
+		{' ', 0, false, false, false, false},
+		{'0', 0, true, false, false, false},
+		{'1', LLILostLock, true, true, false, false},
+		{'2', LLIHalfCycle, true, false, true, false},
+		{'4', LLIBOC, true, false, false, true},
+		{'7', LLILostLock | LLIHalfCycle | LLIBOC, true, true, true, true},
+		{'8', 0, false, false, false, false},
+		{'x', 0, false, false, false, false},
+	}
+	for _, tt := range tests {
+		d := SatObsData{LLI: tt.lli}
+		l, ok := d.LossOfLock()
+		if l != tt.want || ok != tt.ok {
+			t.Errorf("LossOfLock(%q) = %d %v, want %d %v", tt.lli, l, ok, tt.want, tt.ok)
+		}
+		if l.LostLock() != tt.lostLock || l.HalfCycle() != tt.halfCycle || l.BOC() != tt.boc {
+			t.Errorf("bits of LLI %q = %v %v %v", tt.lli, l.LostLock(), l.HalfCycle(), l.BOC())
+		}
+	}
+}
+
+func TestSignalStrength(t *testing.T) {
+	tests := []struct {
+		ss     byte
+		want   SignalStrength
+		unit   string
+		lo, hi float64
+		ok     bool
+	}{
+		{' ', 0, "DBHZ", 0, 0, false},
+		{'0', 0, "DBHZ", 0, 0, false},
+		{'1', 1, "DBHZ", 0, 12, true},
+		{'2', 2, "DBHZ", 12, 18, true},
+		{'5', 5, "dBHz", 30, 36, true},
+		{'8', 8, "DBHZ  ", 48, 54, true},
+		{'9', 9, "DBHZ", 54, math.Inf(1), true},
+		{'5', 5, "", 0, 0, false},
+		{'5', 5, "DB", 0, 0, false},
+		{'x', 0, "DBHZ", 0, 0, false},
+	}
+	for _, tt := range tests {
+		d := SatObsData{SS: tt.ss}
+		ss := d.SignalStrength()
+		if ss != tt.want {
+			t.Errorf("SignalStrength(%q) = %d, want %d", tt.ss, ss, tt.want)
+		}
+		if lo, hi, ok := ss.CN0(tt.unit); lo != tt.lo || hi != tt.hi || ok != tt.ok {
+			t.Errorf("CN0(%d, %q) = %g %g %v, want %g %g %v", ss, tt.unit, lo, hi, ok, tt.lo, tt.hi, tt.ok)
+		}
+	}
+}
+
+func TestSignalStrengthUnit(t *testing.T) {
+	const (
+		interval = "    30.000                                                  INTERVAL\n"
+		unit     = "DBHZ                                                        SIGNAL STRENGTH UNIT\n"
+	)
+	b, err := os.ReadFile("testdata/example_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+		want string
+	}{
+		{"dbhz", corrupted(t, "testdata/example_v3.crx", interval, interval+unit), "DBHZ"},
+		{"none", b, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScanner(bytes.NewReader(tt.b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !s.ScanEpoch() {
+				t.Fatal(s.Err())
+			}
+			if got := s.SignalStrengthUnit(); got != tt.want {
+				t.Errorf("SignalStrengthUnit() = %q, want %q", got, tt.want)
+			}
+
+			// G01 C1C of the first epoch: no LLI, signal strength 5
+			d := s.Data()[0].ObsData[0]
+			if l, ok := d.LossOfLock(); l != 0 || ok {
+				t.Errorf("LossOfLock() = %d %v, want 0 false", l, ok)
+			}
+			lo, hi, ok := d.SignalStrength().CN0(s.SignalStrengthUnit())
+			if ok != (tt.want != "") || (ok && (lo != 30 || hi != 36)) {
+				t.Errorf("CN0() = %g %g %v", lo, hi, ok)
+			}
+		})
+	}
+}
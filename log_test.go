@@ -0,0 +1,68 @@
+package crinex
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	b := diagnosed(t)
+
+	// the first records without the time, of the header and the first epoch
+	// of the 7 diagnostics
+	wants := map[string][]string{
+		"Scanner": {
+			`{"level":"WARN","msg":"no header label found: s='no label'","code":"no-header-label","line":5}`,
+			`{"level":"WARN","msg":"length of epoch record is wrong: b='> 2023 01 01 00 00  0.0000000  0  3       G01G02R03'","code":"epoch-record-length","line":11,"epoch":"2023-01-01T00:00:00Z"}`,
+			`{"level":"WARN","msg":"delete an extra space found at the begining of the satellite list.","code":"satlist-repaired","line":11,"epoch":"2023-01-01T00:00:00Z"}`,
+		},
+		// the Reader does not give the epoch
+		"Reader": {
+			`{"level":"WARN","msg":"no header label found: s='no label'","code":"no-header-label","line":5}`,
+			`{"level":"WARN","msg":"length of epoch record is wrong: b='> 2023 01 01 00 00  0.0000000  0  3       G01G02R03'","code":"epoch-record-length","line":11}`,
+			`{"level":"WARN","msg":"delete an extra space found at the begining of the satellite list.","code":"satlist-repaired","line":11}`,
+		},
+	}
+
+	for path, want := range wants {
+		var buf bytes.Buffer
+		opts := Options{Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey && len(groups) == 0 {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))}
+
+		switch path {
+		case "Scanner":
+			s, err := NewScannerWithOptions(bytes.NewReader(b), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for s.ScanEpoch() {
+			}
+		case "Reader":
+			r, err := NewReaderWithOptions(bytes.NewReader(b), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.Copy(io.Discard, r)
+		}
+
+		got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(got) != 7 {
+			t.Fatalf("%s: records:\n%s", path, buf.String())
+		}
+		for i := range want {
+			if !json.Valid([]byte(got[i])) || got[i] != want[i] {
+				t.Errorf("%s: record %d = %s, want %s", path, i, got[i], want[i])
+			}
+		}
+	}
+}
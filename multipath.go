@@ -0,0 +1,309 @@
+package crinex
+
+import (
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ---------------------------------------------------
+// Code multipath
+// ---------------------------------------------------
+
+// defaultMinArc is the default minimum number of epochs of a multipath arc.
+const defaultMinArc = 10
+
+// MultipathOptions configures Multipath.
+type MultipathOptions struct {
+	// MaxGap is the MaxGap of the ArcTracker that follows the phases.
+	MaxGap time.Duration
+
+	// MinArc is the minimum number of epochs of an arc (default: 10).
+	// Shorter arcs are discarded.
+	MinArc int
+
+	// Slip configures the cycle slip detection that breaks the arcs.
+	Slip SlipOptions
+
+	// Series keeps the multipath of every epoch in MultipathSat.Series.
+	Series bool
+}
+
+// MultipathResult is the code multipath reported by Multipath.
+type MultipathResult struct {
+	Sats    []MultipathSat    `json:"sats"`
+	Signals []MultipathSignal `json:"signals"`
+}
+
+// MultipathSat is the multipath of a code observation of a satellite.
+type MultipathSat struct {
+	Sat    string    `json:"sat"`
+	Name   string    `json:"name"`   // e.g. "MP1"
+	Code   string    `json:"code"`   // code observation, e.g. "C1C"
+	Phases [2]string `json:"phases"` // phase observations of the combination, e.g. ["L1C", "L2W"]
+	Arcs   int       `json:"arcs"`
+	Epochs int       `json:"epochs"`
+	RMS    float64   `json:"rms"` // in meters
+
+	Series []MultipathValue `json:"series,omitempty"`
+}
+
+// MultipathValue is the multipath at an epoch.
+type MultipathValue struct {
+	Epoch time.Time `json:"epoch"`
+	MP    float64   `json:"mp"` // in meters, with the arc mean removed
+}
+
+// MultipathSignal is the multipath of a code observation over the satellites
+// of a system.
+type MultipathSignal struct {
+	System string  `json:"system"`
+	Name   string  `json:"name"` // e.g. "MP1"
+	Code   string  `json:"code"` // code observation, e.g. "C1C"
+	Sats   int     `json:"sats"`
+	Epochs int     `json:"epochs"`
+	RMS    float64 `json:"rms"` // in meters
+}
+
+// mpStats accumulates the multipath of a combination.
+type mpStats struct {
+	MultipathSat
+	sumSq float64
+}
+
+// Multipath decodes the Hatanaka RINEX file read from r, and computes the
+// code multipath in the way of teqc:
+//
+//	MPi = Pi - (a+1)/(a-1) Li + 2/(a-1) Lj,  a = (fi/fj)^2
+//
+// where Pi is a code observation of the band i, and Li and Lj are the phase
+// observations in meters of the band i and another band j of the system.
+// The band j is the other band of the pairs used for the cycle slip
+// detection if observed, e.g. MP1 and MP2 with L1 and L2, and MP5 with L1 and
+// L5 for GPS, or any other band with the phase observed, e.g. MP6 with L6 and
+// L1 for Galileo. Every code observation with the phases is used, except for
+// the bands without a known frequency, e.g. the GLONASS FDMA bands of the
+// satellites without the channel.
+//
+// The multipath is computed per arc of the combination, see comboTracker,
+// and the arc mean is removed to eliminate the ambiguities. The RMS is
+// reported for every satellite and code observation, and for every code
+// observation over the satellites of a system.
+func Multipath(r io.Reader, opts MultipathOptions) (res MultipathResult, err error) {
+	s, err := NewScanner(r)
+	if err != nil {
+		return res, err
+	}
+	if err := s.ParseHeader(); err != nil {
+		return res, err
+	}
+	if opts.MinArc <= 0 {
+		opts.MinArc = defaultMinArc
+	}
+
+	var (
+		tracker = newComboTracker(opts.MaxGap, opts.MinArc, opts.Slip)
+		stats   = make(map[comboKey]*mpStats)
+	)
+	addArcs := func(arcs []*comboArc) {
+		for _, a := range arcs {
+			mean := 0.0
+			for _, v := range a.values {
+				mean += v[0]
+			}
+			mean /= float64(len(a.values))
+
+			st, ok := stats[a.key]
+			if !ok {
+				code := a.key.codes[0]
+				st = &mpStats{MultipathSat: MultipathSat{
+					Sat: a.key.sat, Name: "MP" + code[1:2], Code: code, Phases: a.key.phases,
+				}}
+				stats[a.key] = st
+			}
+			st.Arcs++
+			for i, v := range a.values {
+				st.Epochs++
+				st.sumSq += (v[0] - mean) * (v[0] - mean)
+				if opts.Series {
+					st.Series = append(st.Series, MultipathValue{a.epochs[i], v[0] - mean})
+				}
+			}
+		}
+	}
+
+	for s.ScanEpoch() {
+		obs, ended := tracker.update(s)
+		addArcs(ended)
+
+		epoch := s.Epoch()
+		for _, o := range obs {
+			if len(o.ObsData) == 0 {
+				continue
+			}
+			sys := o.SatId[:1]
+			if sys == " " {
+				sys = "G"
+			}
+			codes := s.ObsTypes()[o.SatId[:1]]
+
+			for _, c := range multipathCombinations(o, codes, sys, tracker.freq) {
+				key := comboKey{
+					sat:    o.SatId,
+					phases: [2]string{codes[c.phase[0]], codes[c.phase[1]]},
+					codes:  [2]string{codes[c.code]},
+				}
+				tracker.add(key, epoch, [2]float64{c.mp})
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return res, err
+	}
+	addArcs(tracker.flush())
+
+	// per satellite and per signal
+	type sigKey struct{ sys, code string }
+	sigs := make(map[sigKey]*MultipathSignal)
+	sumSq := make(map[sigKey]float64)
+	for _, st := range stats {
+		st.RMS = math.Sqrt(st.sumSq / float64(st.Epochs))
+		sort.SliceStable(st.Series, func(i, j int) bool { return st.Series[i].Epoch.Before(st.Series[j].Epoch) })
+		res.Sats = append(res.Sats, st.MultipathSat)
+
+		sys := st.Sat[:1]
+		if sys == " " {
+			sys = "G"
+		}
+		k := sigKey{sys, st.Code}
+		sig, ok := sigs[k]
+		if !ok {
+			sig = &MultipathSignal{System: sys, Name: st.Name, Code: st.Code}
+			sigs[k] = sig
+		}
+		sig.Sats++
+		sig.Epochs += st.Epochs
+		sumSq[k] += st.sumSq
+	}
+	for k, sig := range sigs {
+		sig.RMS = math.Sqrt(sumSq[k] / float64(sig.Epochs))
+		res.Signals = append(res.Signals, *sig)
+	}
+
+	sort.Slice(res.Sats, func(i, j int) bool {
+		a, b := res.Sats[i], res.Sats[j]
+		if a.Sat != b.Sat {
+			return a.Sat < b.Sat
+		}
+		return a.Code < b.Code
+	})
+	sort.Slice(res.Signals, func(i, j int) bool {
+		a, b := res.Signals[i], res.Signals[j]
+		if a.System != b.System {
+			return a.System < b.System
+		}
+		return a.Code < b.Code
+	})
+	return res, nil
+}
+
+// mpCombination is a multipath combination observed at an epoch.
+type mpCombination struct {
+	code  int    // index of the code observation
+	phase [2]int // indices of the phase observations of the bands i and j
+	mp    float64
+}
+
+// multipathCombinations returns the multipath of every code observation
+// observed with the phases of its band and another band, see partnerBands.
+func multipathCombinations(o SatObss, codes []string, sys string, freq FreqTable) (combs []mpCombination) {
+	observed := func(j int) bool {
+		return j < len(o.ObsData) && !math.IsNaN(o.ObsData[j].Data)
+	}
+
+	for i, c := range codes {
+		if len(c) < 2 || (c[0] != 'C' && c[0] != 'P') || !observed(i) {
+			continue
+		}
+		band := c[1]
+
+		// the phase of the same signal if observed, or of the band
+		li := -1
+		if attr := c[2:]; attr != "" {
+			if j := slices.Index(codes, "L"+string(band)+attr); j >= 0 && observed(j) {
+				li = j
+			}
+		}
+		if li < 0 {
+			li, _ = signalIndices(o, codes, band)
+		}
+		if li < 0 {
+			continue
+		}
+		fi, ok := freq.Frequency(o.SatId, codes[li])
+		if !ok {
+			continue
+		}
+
+		// the phase of the first other band observed with the frequency
+		for _, other := range partnerBands(sys, band) {
+			lj, _ := signalIndices(o, codes, other)
+			if lj < 0 {
+				continue
+			}
+			fj, ok := freq.Frequency(o.SatId, codes[lj])
+			if !ok {
+				continue
+			}
+
+			a := (fi / fj) * (fi / fj)
+			phi := o.ObsData[li].Data * SpeedOfLight / fi
+			phj := o.ObsData[lj].Data * SpeedOfLight / fj
+			mp := o.ObsData[i].Data - (a+1)/(a-1)*phi + 2/(a-1)*phj
+			combs = append(combs, mpCombination{code: i, phase: [2]int{li, lj}, mp: mp})
+			break
+		}
+	}
+	return combs
+}
+
+// partnerBands returns the candidates of the other band of the multipath
+// combination of the band, in the order of preference: the other bands of
+// the pairs in slipBands with the band, the bands of the other pairs, and
+// the other bands of the system with a known frequency.
+func partnerBands(sys string, band byte) (bands []byte) {
+	add := func(b byte) {
+		if b != band && !slices.Contains(bands, b) {
+			bands = append(bands, b)
+		}
+	}
+	for _, pair := range slipBands[sys] {
+		switch band {
+		case pair[0]:
+			add(pair[1])
+		case pair[1]:
+			add(pair[0])
+		}
+	}
+	for _, pair := range slipBands[sys] {
+		add(pair[0])
+		add(pair[1])
+	}
+
+	var known []byte
+	for b := range carrierFreqs[sys] {
+		known = append(known, b)
+	}
+	if sys == "R" {
+		for b := range glonassFreqs {
+			known = append(known, b)
+		}
+	}
+	slices.Sort(known)
+	for _, b := range known {
+		add(b)
+	}
+	return bands
+}
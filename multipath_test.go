@@ -0,0 +1,95 @@
+package crinex
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMultipathL5Slip(t *testing.T) {
+	f, err := os.Open("testdata/slip_l5.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	res, err := Multipath(f, MultipathOptions{MinArc: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the slip of L5Q breaks MP5 of G01 only
+	arcs := map[string]int{
+		"G01 MP1": 1, "G01 MP2": 1, "G01 MP5": 2,
+		"G02 MP1": 1, "G02 MP2": 1, "G02 MP5": 1,
+	}
+	if len(res.Sats) != len(arcs) {
+		t.Fatalf("sats = %+v", res.Sats)
+	}
+	for _, sat := range res.Sats {
+		want, ok := arcs[sat.Sat+" "+sat.Name]
+		if !ok || sat.Arcs != want || sat.Epochs != 20 {
+			t.Errorf("%s %s: %d arcs of %d epochs, want %d arcs of 20 epochs", sat.Sat, sat.Name, sat.Arcs, sat.Epochs, want)
+		}
+
+		// the multipath of the data is below 0.3 m
+		if sat.RMS > 0.3 {
+			t.Errorf("%s %s: RMS = %.3f", sat.Sat, sat.Name, sat.RMS)
+		}
+	}
+
+	if len(res.Signals) != 3 {
+		t.Fatalf("signals = %+v", res.Signals)
+	}
+	for i, code := range []string{"C1C", "C2W", "C5Q"} {
+		if sig := res.Signals[i]; sig.System != "G" || sig.Code != code || sig.Sats != 2 || sig.Epochs != 40 {
+			t.Errorf("signal %d = %+v", i, sig)
+		}
+	}
+}
+
+func TestMultipathSystems(t *testing.T) {
+	f, err := os.Open("testdata/multi_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	res, err := Multipath(f, MultipathOptions{MinArc: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the phases of every code observation, R10 without the channel is skipped
+	glo := map[string][2]string{"C1C": {"L1C", "L2P"}, "C2P": {"L2P", "L1C"}, "C3Q": {"L3Q", "L1C"}}
+	want := map[string]map[string][2]string{
+		"G01": {"C1C": {"L1C", "L2W"}, "C2W": {"L2W", "L1C"}, "C5Q": {"L5Q", "L1C"}},
+		"R01": glo,
+		"R02": glo,
+		"R09": glo,
+		"E01": {"C1C": {"L1C", "L5Q"}, "C5Q": {"L5Q", "L1C"}, "C7Q": {"L7Q", "L1C"}, "C8Q": {"L8Q", "L1C"}, "C6C": {"L6C", "L1C"}},
+		"C01": {"C2I": {"L2I", "L7I"}, "C7I": {"L7I", "L2I"}, "C6I": {"L6I", "L2I"}, "C8X": {"L8X", "L2I"}},
+		"J01": {"C1C": {"L1C", "L2L"}, "C2L": {"L2L", "L1C"}, "C6L": {"L6L", "L1C"}},
+	}
+	n := 0
+	for _, codes := range want {
+		n += len(codes)
+	}
+	if len(res.Sats) != n {
+		t.Errorf("%d combinations, want %d", len(res.Sats), n)
+	}
+	for _, sat := range res.Sats {
+		phases, ok := want[sat.Sat][sat.Code]
+		if !ok {
+			t.Errorf("%s %s: unexpected combination", sat.Sat, sat.Code)
+			continue
+		}
+		if sat.Name != "MP"+sat.Code[1:2] || sat.Phases != phases || sat.Arcs != 1 || sat.Epochs != 20 {
+			t.Errorf("%s %s = %+v, want %s of %v in 1 arc of 20 epochs", sat.Sat, sat.Code, sat, "MP"+sat.Code[1:2], phases)
+		}
+
+		// the data has no multipath
+		if sat.RMS > 0.01 {
+			t.Errorf("%s %s: RMS = %.4f", sat.Sat, sat.Code, sat.RMS)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package crinex
+
+import "log/slog"
+
+// Options configures a Scanner or a Reader.
+// The zero value provides the default behavior.
+type Options struct {
+	// OnDiagnostic is called for every diagnostic as it is raised.
+	// If set, diagnostics are passed to OnDiagnostic instead of being
+	// accumulated in Scanner.Diagnostics and Scanner.Warnings.
+	// Call Scanner.Abort from OnDiagnostic to stop scanning.
+	OnDiagnostic func(Diagnostic)
+
+	// Logger receives every diagnostic as a structured log record with the
+	// attributes "code", "line", "sat" and "epoch".
+	// Nothing is logged if Logger is nil.
+	Logger *slog.Logger
+
+	// Strict rejects the input instead of repairing it. In the strict mode,
+	// any diagnostic with SeverityWarning or higher, e.g. a repaired epoch
+	// record or a resync to the next initialization flag, stops the decoding
+	// with a *ValidationError.
+	Strict bool
+
+	// CheckSampling reports missing epochs (CodeEpochGap) and epochs off the
+	// sampling grid (CodeEpochOffGrid) as SeverityInfo diagnostics. The
+	// nominal interval is the header INTERVAL, or the modal spacing of the
+	// first epochs if not given; in the latter case, the diagnostics of the
+	// first epochs are raised once the interval is inferred.
+	CheckSampling bool
+
+	// BufferSize is the initial size of the buffer to read lines
+	// (default: 64 KiB). The buffer grows up to MaxLineLength.
+	BufferSize int
+
+	// Limits for hostile input. Exceeding a limit stops the decoding with a
+	// *LimitError. Zero means no limit other than the format, except for
+	// MaxLineLength.
+	MaxLineLength   int   // maximum length of a line in bytes (default: 1 MiB)
+	MaxSatellites   int   // maximum number of satellites in an epoch
+	MaxObsTypes     int   // maximum number of observation types for a satellite system
+	MaxDiff         int   // maximum order of difference
+	MaxDecodedBytes int64 // maximum total bytes of the decoded RINEX, including the headers
+}
+
+// validate returns a *ValidationError if d is not acceptable in the strict
+// mode, otherwise returns nil.
+func (o *Options) validate(d Diagnostic) error {
+	if o.Strict && d.Severity >= SeverityWarning {
+		return &ValidationError{d}
+	}
+	return nil
+}
+
+// defaultBufferSize is the default initial size of the buffer to read lines.
+const defaultBufferSize = 64 * 1024
+
+// defaultMaxLineLength is the default maximum length of a line.
+const defaultMaxLineLength = 1024 * 1024
+
+// bufferSize returns the initial size of the buffer to read lines.
+func (o *Options) bufferSize() int {
+	n := defaultBufferSize
+	if o.BufferSize > 0 {
+		n = o.BufferSize
+	}
+
+	// the buffer never exceeds the maximum token size
+	if m := o.maxLineLength() + 2; n > m {
+		n = m
+	}
+	return n
+}
+
+// maxLineLength returns the maximum length of a line.
+func (o *Options) maxLineLength() int {
+	if o.MaxLineLength > 0 {
+		return o.MaxLineLength
+	}
+	return defaultMaxLineLength
+}
@@ -0,0 +1,196 @@
+package crinex
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"time"
+)
+
+// ---------------------------------------------------
+// Probing a file without full decoding
+// ---------------------------------------------------
+
+// Info describes a Hatanaka RINEX file probed by Probe.
+type Info struct {
+	CRINEXVersion string              // "1.0", "3.0" or "3.1"
+	RINEXVersion  string              // e.g. "3.04"
+	Header        []byte              // RINEX header
+	Systems       []string            // satellite systems, e.g. ["G", "R", "E"]
+	ObsTypes      map[string][]string // obstypes of the systems
+	Interval      time.Duration       // INTERVAL, or the spacing of the first epochs
+	First         time.Time           // first epoch
+	Last          time.Time           // last epoch
+	Epochs        int                 // number of epochs estimated from the interval
+}
+
+// probeChunkSize is the initial size of the tail searched for the last
+// initialization epoch.
+const probeChunkSize = 64 * 1024
+
+// Probe reads the header and the first and last epochs of the Hatanaka RINEX
+// file without decoding every epoch.
+//
+// The first epochs are decoded from the beginning of the file. Then the file
+// is searched backwards from the end for the last initialization epoch ('>'
+// or '&'), and only the epoch records following it are decoded to find the
+// last epoch. Files without initialization epochs other than the first one
+// are walked to the end, but only the epoch records are decoded. The number
+// of epochs is estimated from the time span and the interval, so gaps are not
+// taken into account.
+func Probe(r io.ReadSeeker) (info Info, err error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return info, err
+	}
+
+	// header and the first epochs
+	s, err := NewScanner(r)
+	if err != nil {
+		return info, err
+	}
+	if err := s.ParseHeader(); err != nil {
+		return info, err
+	}
+	lines := headerLines(s.Header())
+
+	info.CRINEXVersion = s.ver
+	info.Header = s.Header()
+	if l, ok := findHeaderLine(lines, labelRinexVersion); ok {
+		info.RINEXVersion = trimHeaderValue(l, 0, 20)
+	}
+
+	if !s.ScanEpoch() {
+		if err := s.Err(); err != nil {
+			return info, err
+		}
+		// no epoch
+		info.Systems, info.ObsTypes = probeSystems(s, lines)
+		return info, nil
+	}
+	info.First = s.Epoch()
+	info.Last = s.Epoch()
+	info.Systems, info.ObsTypes = probeSystems(s, lines)
+
+	if v, ok := headerInterval(lines); ok {
+		info.Interval = time.Duration(v * float64(time.Second))
+	} else if s.ScanEpoch() {
+		info.Interval = s.Epoch().Sub(info.First)
+		info.Last = s.Epoch()
+	}
+
+	// last epoch
+	last, err := probeLastEpoch(r, s.ver)
+	if err != nil {
+		return info, err
+	}
+	if last.After(info.Last) {
+		info.Last = last
+	}
+
+	info.Epochs = 1
+	if info.Interval > 0 {
+		info.Epochs += int((info.Last.Sub(info.First) + info.Interval/2) / info.Interval)
+	}
+	return info, nil
+}
+
+// probeSystems returns the satellite systems and the obstypes. The systems of
+// RINEX 2 are given by the header and the satellites of the current epoch.
+func probeSystems(s *Scanner, lines []string) (systems []string, obsTypes map[string][]string) {
+	if s.ver != "1.0" {
+		systems = obsTypesOrder(lines)
+		obsTypes = make(map[string][]string)
+		for _, sys := range systems {
+			obsTypes[sys] = s.ObsTypes()[sys]
+		}
+		return systems, obsTypes
+	}
+
+	if l, ok := findHeaderLine(lines, labelRinexVersion); ok {
+		switch sys := trimHeaderValue(l, 40, 41); sys {
+		case "M":
+		case "":
+			systems = append(systems, "G")
+		default:
+			systems = append(systems, sys)
+		}
+	}
+	for _, satId := range s.SatList() {
+		sys := satId[:1]
+		if sys == " " {
+			sys = "G"
+		}
+		if slices.Contains(VALID_SATSYS, sys) && !slices.Contains(systems, sys) {
+			systems = append(systems, sys)
+		}
+	}
+
+	obsTypes = make(map[string][]string)
+	for _, sys := range systems {
+		obsTypes[sys] = s.ObsTypes()[sys]
+	}
+	return systems, obsTypes
+}
+
+// probeLastEpoch searches r backwards from the end for the last
+// initialization epoch, and returns the time of the last epoch decoded from
+// there. A zero time is returned if no initialization epoch is found.
+func probeLastEpoch(r io.ReadSeeker, ver string) (last time.Time, err error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return last, err
+	}
+
+	for n := int64(probeChunkSize); ; n *= 2 {
+		if n > size {
+			n = size
+		}
+
+		buf := make([]byte, n)
+		if _, err := r.Seek(size-n, io.SeekStart); err != nil {
+			return last, err
+		}
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return last, err
+		}
+
+		// the last initialization epoch in the tail, excluding the first line
+		// that may be partial
+		for i := len(buf) - 1; i > 0; i-- {
+			if i = bytes.LastIndexByte(buf[:i], '\n'); i < 0 {
+				break
+			}
+			if t, ok := probeFrom(buf[i+1:], ver); ok {
+				return t, nil
+			}
+		}
+
+		if n == size {
+			return last, nil
+		}
+	}
+}
+
+// probeFrom decodes the epoch records from the beginning of b and returns the
+// time of the last epoch, if b begins with an initialization epoch.
+func probeFrom(b []byte, ver string) (last time.Time, ok bool) {
+	if len(b) == 0 || (b[0] != '>' && b[0] != '&') {
+		return last, false
+	}
+	line, _, _ := bytes.Cut(b, []byte{'\n'})
+	if init, event, _, err := checkInitialized(string(bytes.TrimRight(line, "\r"))); !init || event || err != nil {
+		return last, false
+	}
+
+	c := cutter{s: newLineScanner(bytes.NewReader(b), Options{}), ver: ver}
+	for {
+		e, err := c.next()
+		if err != nil {
+			// the end of the file, or a truncated last epoch
+			return last, ok
+		}
+		if !e.event {
+			last, ok = e.epoch, true
+		}
+	}
+}
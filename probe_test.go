@@ -0,0 +1,68 @@
+package crinex
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProbe(t *testing.T) {
+	at := func(min, sec int) time.Time { return time.Date(2023, 1, 1, 0, min, sec, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		crinex   string
+		rinex    string
+		systems  []string
+		obsTypes map[string][]string
+		first    time.Time
+		last     time.Time
+		epochs   int
+	}{
+		// the last epoch after the last initialization epoch
+		{"event_v3.crx", "3.0", "3.04", []string{"G", "R"},
+			map[string][]string{"G": {"C1C", "L1C", "C2W", "L2W"}, "R": {"C1C", "L1C"}}, at(0, 0), at(1, 30), 4},
+		// without initialization epochs other than the first one
+		{"example_v3.crx", "3.0", "3.04", []string{"G", "R"},
+			map[string][]string{"G": {"C1C", "L1C", "C2W", "L2W"}, "R": {"C1C", "L1C"}}, at(0, 0), at(1, 30), 4},
+		{"example_v1.crx", "1.0", "2.11", []string{"G"},
+			map[string][]string{"G": {"C1", "L1", "P2", "L2"}},
+			time.Date(1999, 6, 12, 0, 14, 0, 0, time.UTC), time.Date(1999, 6, 12, 0, 14, 30, 0, time.UTC), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile("testdata/" + tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			info, err := Probe(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.CRINEXVersion != tt.crinex || info.RINEXVersion != tt.rinex {
+				t.Errorf("versions = %s %s, want %s %s", info.CRINEXVersion, info.RINEXVersion, tt.crinex, tt.rinex)
+			}
+			if !slices.Equal(info.Systems, tt.systems) {
+				t.Errorf("systems = %v, want %v", info.Systems, tt.systems)
+			}
+			for _, sys := range tt.systems {
+				if !slices.Equal(info.ObsTypes[sys], tt.obsTypes[sys]) {
+					t.Errorf("obstypes of %s = %v, want %v", sys, info.ObsTypes[sys], tt.obsTypes[sys])
+				}
+			}
+			if info.Interval != 30*time.Second {
+				t.Errorf("interval = %v", info.Interval)
+			}
+			if !info.First.Equal(tt.first) || !info.Last.Equal(tt.last) || info.Epochs != tt.epochs {
+				t.Errorf("%v - %v, %d epochs, want %v - %v, %d epochs", info.First, info.Last, info.Epochs, tt.first, tt.last, tt.epochs)
+			}
+			if !bytes.HasSuffix(info.Header, []byte("END OF HEADER\n")) {
+				t.Errorf("header:\n%s", info.Header)
+			}
+		})
+	}
+}
@@ -6,18 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"math"
-	"os"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// crinex logger
-var logger = log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile)
-
 var (
 	ErrBadMagic            = errors.New("crinex: Bad magic value")
 	ErrNotSupportedVersion = errors.New("crinex: Not supported version")
@@ -27,41 +22,157 @@ var (
 	ErrInvalidMaxDiff      = errors.New("crinex: Invalid maxdiff found")
 	ErrInvalidSatList      = errors.New("crinex: Invalid satellite list found")
 	ErrRecovered           = errors.New("crinex: Invalid record found and recovered")
+	ErrAborted             = errors.New("crinex: Aborted")
+	ErrStrict              = errors.New("crinex: Rejected in strict mode")
+	ErrTruncated           = errors.New("crinex: Truncated file")
+	ErrLimitExceeded       = errors.New("crinex: Limit exceeded")
+	ErrIncompatible        = errors.New("crinex: Incompatible files")
 )
 
+// NewReader returns a reader that provides the RINEX contents decoded from
+// the Hatanaka RINEX read from r, with the default options.
 func NewReader(r io.Reader) (io.Reader, error) {
+	return NewReaderWithOptions(r, Options{})
+}
+
+// NewReaderWithOptions returns a reader that provides the RINEX contents
+// decoded from the Hatanaka RINEX read from r, configured by opts.
+// Options.OnDiagnostic, Options.Logger, Options.Strict and the limits are
+// used by the reader.
+//
+// If the file ends in the middle of an epoch, the returned reader provides the
+// contents with the truncated epoch shrunk to the satellites decoded, as
+// Scanner.ScanEpoch does, and a *TruncatedError is returned.
+func NewReaderWithOptions(r io.Reader, opts Options) (io.Reader, error) {
 	var (
 		epochStr string
 		clockStr string
 		buf      []byte
 
 		epochRec strRecord
-		data     map[string]satDataRecord
+		data     = make(map[string]satDataRecord)
 		clk      diffRecord
+		picoSec  strRecord
 	)
 
 	// setup new crxReader
-	s, ver, _, err := setup(r)
+	s, ver, lineNum, err := setup(r, opts)
 	if err != nil {
 		return r, err
 	}
 
-	_ = ver
+	var (
+		obsTypes map[string][]string
+		mark     int // length of buf up to the last epoch, for MaxDecodedBytes
+	)
 
-	// parse obsTypes and get all header contents
-	obsTypes, headers, _, warns, err := scanHeader(s)
-	if err != nil {
+	// readHeader parses obsTypes and appends all header contents to buf
+	readHeader := func() error {
+		var (
+			headers []byte
+			lines   int
+			warns   []Diagnostic
+			err     error
+		)
+		obsTypes, headers, lines, warns, err = scanHeader(s)
+		if err != nil {
+			return err
+		}
+		for _, d := range warns {
+			d.Pos += lineNum
+			if err := report(opts, d); err != nil {
+				return err
+			}
+		}
+		lineNum += lines
+		if err := opts.checkObsTypes(obsTypes, lineNum); err != nil {
+			return err
+		}
+
+		if err := checkLimit("MaxDecodedBytes", int64(len(buf)+len(headers)), opts.MaxDecodedBytes, lineNum); err != nil {
+			return err
+		}
+		buf = append(buf, headers...) // add header
+		mark = len(buf)
+		return nil
+	}
+
+	if err := readHeader(); err != nil {
 		return bytes.NewReader(buf), err
 	}
-	for _, w := range warns {
-		logger.Printf("[warning] line=%d: %s\n", w.Pos, w.Msg)
+
+	// scan advances s to the next line and counts the line number
+	scan := func() bool {
+		ok := s.Scan()
+		if ok {
+			lineNum++
+		}
+		return ok
 	}
 
-	buf = append(buf, headers...) // add header
+	// epochOf returns the time tag of the epoch record, zero if unknown
+	epochOf := func(rec []byte) time.Time {
+		t, _ := epochRecBytestoTime(rec, ver)
+		return t
+	}
 
-	for s.Scan() {
+	// readEvent appends the special event record epochStr and the numSkip
+	// records following it to buf. A truncated event is not output, as in
+	// Scanner.
+	readEvent := func(epochStr string, epochLineNum, numSkip int) error {
+		ev := append(eventAsBytes(epochStr, ver), '\n')
+		for i := 0; i < numSkip; i++ {
+			if !scan() {
+				return truncated(s, Truncation{EpochLine: epochLineNum, Line: lineNum, Epoch: epochOf([]byte(epochStr))})
+			}
+			ev = append(ev, s.Text()...)
+			ev = append(ev, '\n')
+		}
+		buf = append(buf, ev...)
+		return nil
+	}
+
+	// decodeFailed reports a record of the current line that could not be
+	// decoded. Returns a *ValidationError in the strict mode.
+	decodeFailed := func(sat string, err error) error {
+		return report(opts, Diagnostic{
+			Code:     CodeInvalidEpoch,
+			Severity: SeverityError,
+			Pos:      lineNum,
+			Sat:      sat,
+			Line:     s.Text(),
+			Msg:      fmt.Sprintf("failed to decode record: %v", err),
+		})
+	}
+
+	for scan() {
 		// update epoch record
 		epochStr = s.Text()
+		epochLineNum := lineNum
+
+		// a new file begins in the concatenated stream.
+		// The header is output again, and the decoded data are reset.
+		if isCRINEXMagic(epochStr) {
+			if ver, err = parseMagic(epochStr); err != nil {
+				return bytes.NewReader(buf), err
+			}
+			report(opts, fileBoundaryDiagnostic(lineNum, epochStr))
+
+			// skip "CRINEX PROG / DATE"
+			if !scan() {
+				return bytes.NewReader(buf), truncated(s, Truncation{EpochLine: epochLineNum, Line: lineNum})
+			}
+			if err := readHeader(); err != nil {
+				return bytes.NewReader(buf), err
+			}
+
+			epochRec = strRecord{}
+			data = make(map[string]satDataRecord)
+			clk = diffRecord{}
+			picoSec = strRecord{}
+			continue
+		}
+
 		if strings.HasPrefix(epochStr, ">") {
 			// crinex ver 3.0
 			// check special event
@@ -69,12 +180,8 @@ func NewReader(r io.Reader) (io.Reader, error) {
 				numSkip, err := strconv.Atoi(strings.TrimSpace(string(epochStr[32:35])))
 				if err == nil {
 					// special event found, skip numSkip lines
-					buf = append(buf, epochStr...)
-					buf = append(buf, '\n')
-					for i := 0; i < numSkip; i++ {
-						s.Scan()
-						buf = append(buf, s.Text()...)
-						buf = append(buf, '\n')
+					if err := readEvent(epochStr, epochLineNum, numSkip); err != nil {
+						return bytes.NewReader(buf), err
 					}
 					continue
 				} else {
@@ -92,12 +199,8 @@ func NewReader(r io.Reader) (io.Reader, error) {
 				numSkip, err := strconv.Atoi(strings.TrimSpace(string(epochStr[29:32])))
 				if err == nil {
 					// special event found, skip numSkip lines
-					buf = append(buf, epochStr...)
-					buf = append(buf, '\n')
-					for i := 0; i < numSkip; i++ {
-						s.Scan()
-						buf = append(buf, s.Text()...)
-						buf = append(buf, '\n')
+					if err := readEvent(epochStr, epochLineNum, numSkip); err != nil {
+						return bytes.NewReader(buf), err
 					}
 					continue
 				} else {
@@ -108,30 +211,78 @@ func NewReader(r io.Reader) (io.Reader, error) {
 			// initialize epoch record
 			epochRec.buf = []byte(epochStr)
 			data = make(map[string]satDataRecord)
-		} else {
-			epochRec.Decode(epochStr)
+		} else if err := epochRec.Decode(epochStr); err != nil {
+			if err := decodeFailed("", err); err != nil {
+				return bytes.NewReader(buf), err
+			}
 		}
 
 		// receiver clock
-		s.Scan()
+		if !scan() {
+			return bytes.NewReader(buf), truncated(s, Truncation{EpochLine: epochLineNum, Line: lineNum, Epoch: epochOf(epochRec.Bytes()),
+				Expected: epochRec.numSatellites(ver)})
+		}
 		clockStr = s.Text()
-		clk.Decode([]byte(clockStr))
+		vals := strings.SplitN(clockStr, " ", 2) // receiver clock offset & pico-second part of the epoch
+		if err := clk.Decode([]byte(vals[0])); err != nil {
+			if err := decodeFailed("", err); err != nil {
+				return bytes.NewReader(buf), err
+			}
+		}
+		if err := checkLimit("MaxDiff", int64(clk.MaxDiff), int64(opts.MaxDiff), lineNum); err != nil {
+			return bytes.NewReader(buf), err
+		}
+
+		// pico-second record of CRINEX>=3.1
+		if ver >= "3.1" && len(vals) >= 2 {
+			if vals[1] == "&" {
+				// the pico-second record is removed
+				picoSec = strRecord{}
+			} else {
+				picoSec.Decode(vals[1])
+			}
+		}
+		if b := picoSec.Bytes(); len(b) > 0 && (len(b) != 5 || !allBytesAreNumeric(b)) {
+			if err := report(opts, Diagnostic{
+				Code:     CodeInvalidPicoSec,
+				Severity: SeverityWarning,
+				Pos:      lineNum,
+				Epoch:    epochOf(epochRec.Bytes()),
+				Line:     clockStr,
+				Msg:      fmt.Sprintf("non-numeric entries found in the pico-second record: picoSec='%s'", b),
+			}); err != nil {
+				return bytes.NewReader(buf), err
+			}
+		}
 
 		// get list of satellites
-		satList, warns, err := getSatListWithCorrection(epochRec.Bytes(), ver, -1)
+		satList, warns, err := getSatListWithCorrection(epochRec.Bytes(), ver, epochLineNum)
 		if err != nil {
 			return bytes.NewReader(buf), err
 		}
-		for _, w := range warns {
-			logger.Printf("[warning] line=%d: %s\n", w.Pos, w.Msg)
+		if err := checkLimit("MaxSatellites", int64(len(satList)), int64(opts.MaxSatellites), epochLineNum); err != nil {
+			return bytes.NewReader(buf), err
+		}
+		for _, d := range warns {
+			if err := report(opts, d); err != nil {
+				return bytes.NewReader(buf), err
+			}
 		}
 
 		// read data block
-		for _, satId := range satList {
+		var trunc *Truncation
+		for i, satId := range satList {
 			satSys := satId[:1]
 			obsCodes := obsTypes[satSys]
 
-			s.Scan()
+			if !scan() {
+				if err := s.Err(); err != nil {
+					return bytes.NewReader(buf), err
+				}
+				trunc = &Truncation{EpochLine: epochLineNum, Line: lineNum, Epoch: epochOf(epochRec.Bytes()),
+					Expected: len(satList), Decoded: i}
+				break
+			}
 			t := s.Text()
 			vals := strings.SplitN(t, " ", len(obsCodes)+1)
 
@@ -152,7 +303,14 @@ func NewReader(r io.Reader) (io.Reader, error) {
 				}
 
 				b := []byte(vals[j])
-				dj.Decode(b)
+				if err := dj.Decode(b); err != nil {
+					if err := decodeFailed(satId, err); err != nil {
+						return bytes.NewReader(buf), err
+					}
+				}
+				if err := checkLimit("MaxDiff", int64(dj.MaxDiff), int64(opts.MaxDiff), lineNum); err != nil {
+					return bytes.NewReader(buf), err
+				}
 
 				// initialize arc
 				if ver == "1.0" && len(b) > 1 && b[1] == '&' {
@@ -179,17 +337,32 @@ func NewReader(r io.Reader) (io.Reader, error) {
 			}
 		}
 
+		if trunc != nil {
+			if trunc.Decoded == 0 {
+				return bytes.NewReader(buf), &TruncatedError{*trunc}
+			}
+
+			// the truncated epoch is shrunk to the satellites decoded, as
+			// in Scanner
+			satList = satList[:trunc.Decoded]
+			epochRec.setNumSatellites(ver, trunc.Decoded)
+		}
+
 		// ----- CRX to RINEX -----
 		// buffer data in the RINEX format
 		// epoch record
 		switch ver {
 		case "3.0", "3.1":
 			// epoch record
-			if clk.missing {
-				buf = append(buf, fmt.Sprintf("%-35.35s\n", epochRec.StringRINEX())...)
-			} else {
-				buf = append(buf, fmt.Sprintf("%-35.35s      %15.12f\n", epochRec.StringRINEX(), float64(clk.refData)*0.000000000001)...)
+			clkOff := math.NaN()
+			if !clk.missing {
+				clkOff = float64(clk.refData) * 0.000000000001
+			}
+			var pico []byte
+			if b, ok := picoSecondsBytes(picoSec.Bytes()); ok {
+				pico = b[:]
 			}
+			buf = append(buf, epochRec.BytesRINEX(clkOff, pico)...)
 
 			// data block
 			for _, satId := range satList {
@@ -203,6 +376,11 @@ func NewReader(r io.Reader) (io.Reader, error) {
 						continue
 					}
 					//bufs = append(bufs, fmt.Sprintf("%14.3f%1c%1c", float64(ref)*0.001, d.lli[k].buf[0], d.ss[k].buf[0])...)
+					if rinexDataOverflow(d1.refData) {
+						if err := report(opts, overflowDiagnostic(epochLineNum, satId, d1.refData)); err != nil {
+							return bytes.NewReader(buf), err
+						}
+					}
 					bufs = append(bufs, intToRinexDataBytes(d1.refData)...)
 					bufs = append(bufs, d.lli[k].buf[0])
 					bufs = append(bufs, d.ss[k].buf[0])
@@ -226,6 +404,11 @@ func NewReader(r io.Reader) (io.Reader, error) {
 					if d1.missing {
 						bufs = append(bufs, "                "...)
 					} else {
+						if rinexDataOverflow(d1.refData) {
+							if err := report(opts, overflowDiagnostic(epochLineNum, satId, d1.refData)); err != nil {
+								return bytes.NewReader(buf), err
+							}
+						}
 						bufs = append(bufs, intToRinexDataBytes(d1.refData)...)
 						bufs = append(bufs, d.lli[k].buf[0])
 						bufs = append(bufs, d.ss[k].buf[0])
@@ -240,30 +423,122 @@ func NewReader(r io.Reader) (io.Reader, error) {
 				}
 			}
 		}
+		// the epoch exceeding the limit is not output, as in Scanner
+		if err := checkLimit("MaxDecodedBytes", int64(len(buf)), opts.MaxDecodedBytes, epochLineNum); err != nil {
+			return bytes.NewReader(buf[:mark]), err
+		}
+		mark = len(buf)
+
+		if trunc != nil {
+			return bytes.NewReader(buf), &TruncatedError{*trunc}
+		}
 
 	}
+	if err := s.Err(); err != nil {
+		return bytes.NewReader(buf), err
+	}
 
 	return bytes.NewReader(buf), nil
 }
 
+// truncated returns the error of s if any, otherwise returns a
+// *TruncatedError for t. This is called when s stops in the middle of an
+// epoch.
+func truncated(s *bufio.Scanner, t Truncation) error {
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return &TruncatedError{t}
+}
+
+// report passes a diagnostic raised in the reader to opts.OnDiagnostic and
+// opts.Logger. Returns a *ValidationError if d is rejected in the strict mode.
+func report(opts Options, d Diagnostic) error {
+	logDiagnostic(opts.Logger, d)
+
+	if opts.OnDiagnostic != nil {
+		opts.OnDiagnostic(d)
+	}
+	return opts.validate(d)
+}
+
+// newLineScanner returns a bufio.Scanner that reads lines from r.
+// Lines end with LF or CRLF, and all the trailing CRs are removed from the
+// lines. The last line may lack the line ending.
+// The scanner stops with a *LimitError if a line is longer than
+// opts.MaxLineLength.
+func newLineScanner(r io.Reader, opts Options) *bufio.Scanner {
+	var (
+		lineNum int
+		maxLen  = opts.maxLineLength()
+	)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, opts.bufferSize()), maxLen+2) // room for CR and LF
+
+	s.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		// check the line length before bufio.ErrTooLong is raised
+		n := bytes.IndexByte(data, '\n')
+		if n < 0 {
+			n = len(data)
+			if !atEOF && n <= maxLen+1 {
+				n = 0 // request more data
+			}
+		}
+		if l := len(bytes.TrimRight(data[:n], "\r")); l > maxLen {
+			return 0, nil, &LimitError{Limit: "MaxLineLength", Value: int64(l), Max: int64(maxLen), Line: lineNum + 1}
+		}
+
+		advance, token, err = bufio.ScanLines(data, atEOF)
+		if token != nil {
+			// strip CRs left by "\r\r\n" line endings
+			token = bytes.TrimRight(token, "\r")
+		}
+		if advance > 0 {
+			lineNum++
+		}
+		return
+	})
+
+	return s
+}
+
 // setup parses the first two lines of the Hatanaka RINEX and returns
 // scanner and version. The first two lines contain Hatanaka RINEX header.
 // The file position will be advanced 2 lines after the call.
-func setup(r io.Reader) (s *bufio.Scanner, ver string, lines int, err error) {
-	s = bufio.NewScanner(r)
-	if err = s.Err(); err != nil {
-		return s, ver, lines, err
-	}
+func setup(r io.Reader, opts Options) (s *bufio.Scanner, ver string, lines int, err error) {
+	s = newLineScanner(r, opts)
 
 	// check first line: "CRINEX VERS   / TYPE"
 	// "3.0                 COMPACT RINEX FORMAT"
+	if !s.Scan() && s.Err() != nil {
+		return s, ver, lines, s.Err()
+	}
+	lines++
+
+	if ver, err = parseMagic(s.Text()); err != nil {
+		return s, ver, lines, err
+	}
+
+	// skip second line: "CRINEX PROG / DATE"
 	s.Scan()
 	lines++
-	t := s.Text()
 
+	return s, ver, lines, nil
+}
+
+// isCRINEXMagic reports whether t is the first line of the Hatanaka RINEX,
+// "CRINEX VERS   / TYPE".
+func isCRINEXMagic(t string) bool {
+	return len(t) >= 40 && t[20:40] == "COMPACT RINEX FORMAT"
+}
+
+// parseMagic parses the first line of the Hatanaka RINEX and returns the
+// version.
+func parseMagic(t string) (ver string, err error) {
 	// check header
 	if len(t) < 40 {
-		return s, ver, lines, ErrBadMagic
+		return ver, ErrBadMagic
 	}
 
 	ver = strings.TrimSpace(t[:20])
@@ -271,23 +546,31 @@ func setup(r io.Reader) (s *bufio.Scanner, ver string, lines int, err error) {
 
 	//3.0                 COMPACT RINEX FORMAT                    CRINEX VERS   / TYPE
 	if magic != "COMPACT RINEX FORMAT" {
-		return s, ver, lines, ErrBadMagic
+		return ver, ErrBadMagic
 	}
 	if ver != "3.1" && ver != "3.0" && ver != "1.0" {
-		return s, ver, lines, ErrNotSupportedVersion
+		return ver, ErrNotSupportedVersion
 	}
 
-	// skip second line: "CRINEX PROG / DATE"
-	s.Scan()
-	lines++
+	return ver, nil
+}
 
-	return s, ver, lines, nil
+// fileBoundaryDiagnostic returns a Diagnostic for a new file found in the
+// concatenated stream.
+func fileBoundaryDiagnostic(lineNum int, t string) Diagnostic {
+	return Diagnostic{
+		Code:     CodeFileBoundary,
+		Severity: SeverityInfo,
+		Pos:      lineNum,
+		Line:     t,
+		Msg:      "new file begins in the concatenated stream",
+	}
 }
 
 // scanHeader parses the header, stores header contents and obstypes to
 // s.header and s.obsTypes, and advance reader position to the head of
 // the first data block.
-func scanHeader(s *bufio.Scanner) (obsTypes map[string][]string, h []byte, lines int, warnings WarningList, err error) {
+func scanHeader(s *bufio.Scanner) (obsTypes map[string][]string, h []byte, lines int, warnings []Diagnostic, err error) {
 	var (
 		obsTypesStrings   []string
 		obsTypesStringsV2 []string
@@ -304,7 +587,13 @@ func scanHeader(s *bufio.Scanner) (obsTypes map[string][]string, h []byte, lines
 		buf := s.Text()
 		if len(buf) < 61 {
 			// no header label found, and read as a comment
-			warnings.Add(lines, fmt.Sprintf("no header label found: s='%s'", buf))
+			warnings = append(warnings, Diagnostic{
+				Code:     CodeNoHeaderLabel,
+				Severity: SeverityWarning,
+				Pos:      lines,
+				Line:     buf,
+				Msg:      fmt.Sprintf("no header label found: s='%s'", buf),
+			})
 			buf = fmt.Sprintf("%-60sCOMMENT", buf)
 		}
 
@@ -312,8 +601,10 @@ func scanHeader(s *bufio.Scanner) (obsTypes map[string][]string, h []byte, lines
 		h = append(h, byte('\n'))
 
 		if strings.HasPrefix(buf[60:], "RINEX VERSION / TYPE") {
-			rinexVer = strings.TrimSpace(buf[:20])[0] // '2', '3', or '4'
-			RinexVerIsOk = true
+			if v := strings.TrimSpace(buf[:20]); len(v) > 0 {
+				rinexVer = v[0] // '2', '3', or '4'
+				RinexVerIsOk = true
+			}
 		}
 		if strings.HasPrefix(buf[60:], "SYS / # / OBS TYPES") {
 			obsTypesStrings = append(obsTypesStrings, buf)
@@ -329,6 +620,9 @@ func scanHeader(s *bufio.Scanner) (obsTypes map[string][]string, h []byte, lines
 
 	// check if header is ok
 	switch {
+	case s.Err() != nil:
+		err = s.Err()
+		return
 	case !RinexVerIsOk:
 		err = fmt.Errorf("%w: RINEX version not found", ErrInvalidHeader)
 		return
@@ -345,7 +639,12 @@ func scanHeader(s *bufio.Scanner) (obsTypes map[string][]string, h []byte, lines
 			// obstypes header is not correct, but only show a warning
 			// because the number of observation types could be inferred from
 			// the first initialization line.
-			warnings.Add(lines, fmt.Sprintf("failed to parse obstypes: %v", e))
+			warnings = append(warnings, Diagnostic{
+				Code:     CodeInvalidObsTypes,
+				Severity: SeverityWarning,
+				Pos:      lines,
+				Msg:      fmt.Sprintf("failed to parse obstypes: %v", e),
+			})
 		}
 	} else if rinexVer >= '2' {
 		obsTypes, e = parseObsTypesV2(obsTypesStringsV2)
@@ -353,7 +652,12 @@ func scanHeader(s *bufio.Scanner) (obsTypes map[string][]string, h []byte, lines
 			// obstypes header is not correct, but only show a warning
 			// because the number of observation types could be inferred from
 			// the first initialization line.
-			warnings.Add(lines, fmt.Sprintf("failed to parse obstypes: %v", e))
+			warnings = append(warnings, Diagnostic{
+				Code:     CodeInvalidObsTypes,
+				Severity: SeverityWarning,
+				Pos:      lines,
+				Msg:      fmt.Sprintf("failed to parse obstypes: %v", e),
+			})
 		}
 	} else {
 		// not supported
@@ -432,6 +736,10 @@ func parseObsTypesV2(buf []string) (obsTypes map[string][]string, err error) {
 
 	s = buf[0]
 	sep := strings.Fields(s[:60])
+	if len(sep) == 0 {
+		err = fmt.Errorf("failed to parse obsTypes, s='%s'", s)
+		return
+	}
 	sep = sep[1:] // remove the first element that indicates the numCodes
 
 	// parse number of obsCodes
@@ -525,7 +833,7 @@ func epochRecBytestoTime(b []byte, ver string) (t time.Time, err error) {
 			errs                       [7]error
 		)
 
-		if len(b) < 25 {
+		if len(b) < 26 {
 			// too short string
 			return t, ErrInvalidEpochStr
 		}
@@ -599,8 +907,9 @@ func getSatListV1(b []byte) []string {
 
 // getSatListWithCorrection returns a slice of satellite IDs.
 // b is a slice of byte contains epoch record and ver is the crinex version (1.0 or 3.0).
-// If invalid satellite id is found, this func attempts to repair it.
-func getSatListWithCorrection(b []byte, ver string, lineNum int) (satList []string, warns WarningList, err error) {
+// If invalid epoch record is found, this func attempts to repair it with the
+// registered RepairRules.
+func getSatListWithCorrection(b []byte, ver string, lineNum int) (satList []string, warns []Diagnostic, err error) {
 	var (
 		offsetNumSat  int
 		offsetSatList int
@@ -612,71 +921,55 @@ func getSatListWithCorrection(b []byte, ver string, lineNum int) (satList []stri
 	case "1.0":
 		offsetNumSat, offsetSatList = OFFSET_NUMSAT_V1, OFFSET_SATLST_V1
 	default:
-		return satList, WarningList{}, ErrNotSupportedVersion
+		return satList, nil, ErrNotSupportedVersion
 	}
 
 	// no satellite list found
 	if len(b) < offsetSatList {
 		err = fmt.Errorf("%w: b='%s'", ErrInvalidSatList, b)
-		return satList, WarningList{}, err
+		return satList, nil, err
 	}
 
 	// get number of satellites
 	n, e := strconv.Atoi(string(bytes.TrimSpace(b[offsetNumSat : offsetNumSat+3])))
 	if e != nil {
 		err = fmt.Errorf("%w: err=%v", ErrInvalidSatList, e)
-		return satList, WarningList{}, err
+		return satList, nil, err
 	}
 
 	// repair invalid epoch record
 	if len(bytes.TrimRight(b, " ")) != offsetSatList+3*n {
-		warns.Add(lineNum, fmt.Sprintf("length of epoch record is wrong: b='%s'", b))
-
-		switch {
-		case len(bytes.TrimRight(b, " ")) < offsetSatList+3*n:
-			// case1:
-			// There is a wrong epoch record at line 281 in jab11630.99d:
-			// `              4 &                            4&19&2 &15&`,
-			// this line represents
-			// ` 99  6 12  0 14  0.0000000  0  8 18 14 27 16 4 19 22 15`.
-			//
-			// However, satID ' 4' should be '  4' correctly.
-			// So here the satellite list is corrected by separating b with a space.
-			// The same issue found in jab11630.99d, jab11640.99d, jab11660.99d,
-			// jab11670.99d, maw10360.99d and maw10860.99d.
-
-			if bb := bytes.Fields(bytes.Trim(b[offsetSatList:], " ")); len(bb) == n {
-				warns.Add(lineNum, "modify to be the correct 3 bytes sat IDs.")
-
-				// rearrange epoch record to be the correct 3 bytes satellite IDs.
-				ss := string(b[:offsetSatList])
-				for _, b1 := range bb {
-					ss += fmt.Sprintf("%3.3s", b1)
-				}
-				b = []byte(ss)
+		warns = append(warns, Diagnostic{
+			Code:     CodeEpochRecLength,
+			Severity: SeverityWarning,
+			Pos:      lineNum,
+			Line:     string(b),
+			Msg:      fmt.Sprintf("length of epoch record is wrong: b='%s'", b),
+		})
+
+		for _, rule := range RepairRules() {
+			fixed, d, ok := rule.Repair(b, ver, n)
+			if !ok {
+				continue
 			}
 
-		case len(bytes.TrimRight(b, " ")) == offsetSatList+3*n+1 && b[offsetSatList] == ' ':
-			// case2:
-			// There is a wrong epoch record at line 433 in jab12250.99d:
-			// `                3                &07&27&18&04&10&02&19&13`,
-			// this line represents
-			// ` 99  8 13  0 20 30.0000000  0  8  07 27 18 04 10 02 19 13`.
-			//
-			// However, there is an extra space before the satellite list.
-			// Correctly, this line would look like:
-			// `                3                07&27&18&04&10&02&19&13` and
-			// ` 99  8 13  0 20 30.0000000  0  8 07 27 18 04 10 02 19 13`.
-			//
-			// So here the extra space is checked and it will be removed.
-			// The same issue found in jab12280.99d, jab12420.99d, jab12370.99d,
-			// jab12830.99d, jab12250.99d, and jab12390.99d.
-			warns.Add(lineNum, "delete an extra space found at the begining of the satellite list.")
+			// repairs are always reported as warnings
+			if d.Code == "" {
+				d.Code = CodeSatListRepaired
+			}
+			if d.Severity < SeverityWarning {
+				d.Severity = SeverityWarning
+			}
+			if d.Msg == "" {
+				d.Msg = fmt.Sprintf("epoch record repaired by %s", rule.Name())
+			}
+			d.Pos, d.Line = lineNum, string(b)
+			warns = append(warns, d)
 
-			// delete the extra space, not modifying the original slice.
-			r := make([]byte, len(b))
-			copy(r, b)
-			b = slices.Delete(r, offsetSatList, offsetSatList+1)
+			b = fixed
+			if len(bytes.TrimRight(b, " ")) == offsetSatList+3*n {
+				break
+			}
 		}
 	}
 
@@ -688,21 +981,14 @@ func getSatListWithCorrection(b []byte, ver string, lineNum int) (satList []stri
 	}
 
 	// check for consistency between numsat and len of satList
-	lens := len(satList)
-	if lens != n {
-		warns.Add(lineNum, fmt.Sprintf("mismatch between number of satellites: ns='%d', satList='%+v'", n, satList))
-
-		// the last index where the satellites list were correctly parsed
-		i := offsetSatList + lens*3
-
-		// try to repair the invalid satID
-		if len(b) > i+2 {
-			bb := b[i : i+2]
-			if satId, ok := repairInvalidSatID(bb); ok {
-				satList = append(satList, satId)
-				warns.Add(lineNum, fmt.Sprintf("modified invalid satellite '%s '->'%s'", string(bb), satId))
-			}
-		}
+	if len(satList) != n {
+		warns = append(warns, Diagnostic{
+			Code:     CodeNumSatMismatch,
+			Severity: SeverityWarning,
+			Pos:      lineNum,
+			Line:     string(b),
+			Msg:      fmt.Sprintf("mismatch between number of satellites: ns='%d', satList='%+v'", n, satList),
+		})
 	}
 
 	return satList, warns, nil
@@ -729,10 +1015,9 @@ func repairInvalidSatID(b []byte) (s string, ok bool) {
 
 // intToRinexDataBytes returns []byte that is equivalent to the output of
 // fmt.Sprintf("%14.3f", float64(n)*0.001)...
+// Values that overflow are clipped, see rinexDataOverflow.
 func intToRinexDataBytes(n int64) []byte {
-	if n > 9999999999999 || n < -999999999999 {
-		logger.Printf("intToRinexDataBytes: value overflow: v='%d'\n", n)
-
+	if rinexDataOverflow(n) {
 		if n > 0 {
 			return []byte("9999999999.999")
 		} else {
@@ -768,6 +1053,23 @@ func intToRinexDataBytes(n int64) []byte {
 	}
 }
 
+// overflowDiagnostic returns a Diagnostic for a value that does not fit in
+// the RINEX format.
+func overflowDiagnostic(lineNum int, satId string, v int64) Diagnostic {
+	return Diagnostic{
+		Code:     CodeValueOverflow,
+		Severity: SeverityWarning,
+		Pos:      lineNum,
+		Sat:      satId,
+		Msg:      fmt.Sprintf("value overflow: v='%d'", v),
+	}
+}
+
+// rinexDataOverflow reports whether n*0.001 does not fit in the RINEX format F14.3.
+func rinexDataOverflow(n int64) bool {
+	return n > 9999999999999 || n < -999999999999
+}
+
 // replaceNonNumericToSpace replaces non numeric characters to spaces.
 func replaceNonNumericToSpace(s string) string {
 	ss := []byte(s)
@@ -0,0 +1,95 @@
+package crinex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// corrupted returns the file with old replaced by new once.
+func corrupted(t *testing.T, name, old, new string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte(old)) {
+		t.Fatalf("%q not found in %s", old, name)
+	}
+	return bytes.Replace(b, []byte(old), []byte(new), 1)
+}
+
+func TestStrictDecodeError(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		old, new string
+		line     int // line of the diagnostic, 0 if valid
+	}{
+		{"data", "testdata/example_v3.crx", "\n1000 5255 1000 4095\n", "\n1x00 5255 1000 4095\n", 17},
+		{"clock", "testdata/example_v1.crx", "3&123456\n", "x&123456\n", 8},
+		{"valid", "testdata/example_v3.crx", "\n", "\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := corrupted(t, tt.file, tt.old, tt.new)
+
+			// Reader
+			_, err := NewReaderWithOptions(bytes.NewReader(b), Options{Strict: true})
+			checkStrictError(t, "Reader", err, tt.line)
+
+			// Scanner
+			s, err := NewScannerWithOptions(bytes.NewReader(b), Options{Strict: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for s.ScanEpoch() {
+			}
+			checkStrictError(t, "Scanner", s.Err(), tt.line)
+		})
+	}
+}
+
+// checkStrictError checks that err is a *ValidationError at the line, or nil
+// if line is 0.
+func checkStrictError(t *testing.T, path string, err error, line int) {
+	t.Helper()
+	if line == 0 {
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", path, err)
+		}
+		return
+	}
+
+	var ve *ValidationError
+	if !errors.As(err, &ve) || !errors.Is(err, ErrStrict) {
+		t.Fatalf("%s: err = %v, want *ValidationError", path, err)
+	}
+	if ve.Code != CodeInvalidEpoch || ve.Pos != line {
+		t.Errorf("%s: diagnostic = %s at line %d, want %s at line %d", path, ve.Code, ve.Pos, CodeInvalidEpoch, line)
+	}
+}
+
+func TestReaderDecodeErrorDiagnostic(t *testing.T) {
+	b := corrupted(t, "testdata/example_v3.crx", "\n1000 5255 1000 4095\n", "\n1x00 5255 1000 4095\n")
+
+	var diags []Diagnostic
+	r, err := NewReaderWithOptions(bytes.NewReader(b), Options{
+		OnDiagnostic: func(d Diagnostic) { diags = append(diags, d) },
+	})
+	if err != nil {
+		t.Fatalf("NewReaderWithOptions: %v", err)
+	}
+	io.Copy(io.Discard, r)
+
+	if len(diags) != 1 || diags[0].Code != CodeInvalidEpoch || diags[0].Sat != "G01" {
+		t.Fatalf("diagnostics = %+v, want one %s of G01", diags, CodeInvalidEpoch)
+	}
+	if !strings.HasPrefix(diags[0].Line, "1x00") {
+		t.Errorf("line = %q", diags[0].Line)
+	}
+}
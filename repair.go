@@ -0,0 +1,183 @@
+package crinex
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ---------------------------------------------------
+// Repair rules for malformed epoch records
+// ---------------------------------------------------
+
+// RepairRule repairs a malformed epoch record.
+//
+// Repair is called with the decoded epoch record, the CRINEX version ("1.0",
+// "3.0" or "3.1") and the number of satellites declared in the record, when
+// the length of the record is inconsistent with the number of satellites.
+// It returns the corrected record and a diagnostic describing the repair, or
+// ok=false if the rule is not applicable. Repair must not modify rec.
+//
+// The line number and the original record of the diagnostic are filled by the
+// caller, and repairs are always reported with SeverityWarning or higher, so
+// that they are rejected in the strict mode.
+type RepairRule interface {
+	Name() string
+	Repair(rec []byte, ver string, numSat int) (fixed []byte, d Diagnostic, ok bool)
+}
+
+var (
+	repairRulesMu sync.RWMutex
+	repairRules   = []RepairRule{
+		splitSatListRule{},
+		extraSpaceSatListRule{},
+		satIDSpaceRule{},
+	}
+)
+
+// RegisterRepairRule appends r to the registry of repair rules.
+// Rules are tried in the order of registration until the epoch record is
+// repaired. The built-in rules are registered first.
+func RegisterRepairRule(r RepairRule) {
+	repairRulesMu.Lock()
+	defer repairRulesMu.Unlock()
+
+	repairRules = append(repairRules, r)
+}
+
+// RepairRules returns the registered repair rules.
+func RepairRules() []RepairRule {
+	repairRulesMu.RLock()
+	defer repairRulesMu.RUnlock()
+
+	return slices.Clone(repairRules)
+}
+
+// satListOffset returns the offset bytes to the satellite list in the epoch
+// record for the CRINEX version.
+func satListOffset(ver string) (offset int, ok bool) {
+	switch ver {
+	case "3.0", "3.1":
+		return OFFSET_SATLST_V3, true
+	case "1.0":
+		return OFFSET_SATLST_V1, true
+	}
+	return 0, false
+}
+
+// splitSatListRule rearranges satellite IDs that are separated by spaces but
+// not aligned to 3 bytes.
+//
+// There is a wrong epoch record at line 281 in jab11630.99d:
+// `              4 &                            4&19&2 &15&`,
+// this line represents
+// ` 99  6 12  0 14  0.0000000  0  8 18 14 27 16 4 19 22 15`.
+//
+// However, satID ' 4' should be '  4' correctly.
+// So here the satellite list is corrected by separating b with a space.
+// The same issue found in jab11630.99d, jab11640.99d, jab11660.99d,
+// jab11670.99d, maw10360.99d and maw10860.99d.
+type splitSatListRule struct{}
+
+func (splitSatListRule) Name() string { return "split-satlist" }
+
+func (splitSatListRule) Repair(rec []byte, ver string, numSat int) (fixed []byte, d Diagnostic, ok bool) {
+	offset, ok := satListOffset(ver)
+	if !ok || len(rec) < offset || len(bytes.TrimRight(rec, " ")) >= offset+3*numSat {
+		return nil, d, false
+	}
+
+	bb := bytes.Fields(bytes.Trim(rec[offset:], " "))
+	if len(bb) != numSat {
+		return nil, d, false
+	}
+
+	// rearrange epoch record to be the correct 3 bytes satellite IDs.
+	fixed = slices.Clone(rec[:offset])
+	for _, b1 := range bb {
+		fixed = append(fixed, fmt.Sprintf("%3.3s", b1)...)
+	}
+
+	d = Diagnostic{
+		Code:     CodeSatListRepaired,
+		Severity: SeverityWarning,
+		Msg:      "modify to be the correct 3 bytes sat IDs.",
+	}
+	return fixed, d, true
+}
+
+// extraSpaceSatListRule deletes an extra space at the beginning of the
+// satellite list.
+//
+// There is a wrong epoch record at line 433 in jab12250.99d:
+// `                3                &07&27&18&04&10&02&19&13`,
+// this line represents
+// ` 99  8 13  0 20 30.0000000  0  8  07 27 18 04 10 02 19 13`.
+//
+// However, there is an extra space before the satellite list.
+// Correctly, this line would look like:
+// `                3                07&27&18&04&10&02&19&13` and
+// ` 99  8 13  0 20 30.0000000  0  8 07 27 18 04 10 02 19 13`.
+//
+// The same issue found in jab12280.99d, jab12420.99d, jab12370.99d,
+// jab12830.99d, jab12250.99d, and jab12390.99d.
+type extraSpaceSatListRule struct{}
+
+func (extraSpaceSatListRule) Name() string { return "extra-space-satlist" }
+
+func (extraSpaceSatListRule) Repair(rec []byte, ver string, numSat int) (fixed []byte, d Diagnostic, ok bool) {
+	offset, ok := satListOffset(ver)
+	if !ok || len(bytes.TrimRight(rec, " ")) != offset+3*numSat+1 || rec[offset] != ' ' {
+		return nil, d, false
+	}
+
+	// delete the extra space, not modifying the original slice.
+	fixed = slices.Delete(slices.Clone(rec), offset, offset+1)
+
+	d = Diagnostic{
+		Code:     CodeSatListRepaired,
+		Severity: SeverityWarning,
+		Msg:      "delete an extra space found at the begining of the satellite list.",
+	}
+	return fixed, d, true
+}
+
+// satIDSpaceRule repairs the last satellite ID of the list that lacks the
+// space between the satellite system and a single digit PRN.
+//
+// Invalid satellite ID found at line 1653 of alic2520.98d:
+// "                3              2 &4 9&  & &&  & &&  &  &"
+// This case the second satellite is " 9 " but correctly it is "  9".
+type satIDSpaceRule struct{}
+
+func (satIDSpaceRule) Name() string { return "satid-space" }
+
+func (satIDSpaceRule) Repair(rec []byte, ver string, numSat int) (fixed []byte, d Diagnostic, ok bool) {
+	offset, ok := satListOffset(ver)
+	if !ok || numSat < 1 {
+		return nil, d, false
+	}
+
+	// the last satellite ID is 2 bytes
+	i := offset + 3*(numSat-1)
+	if len(bytes.TrimRight(rec, " ")) != i+2 || len(rec) < i+2 {
+		return nil, d, false
+	}
+
+	bb := rec[i : i+2]
+	satId, ok := repairInvalidSatID(bb)
+	if !ok {
+		return nil, d, false
+	}
+
+	fixed = append(slices.Clone(rec[:i]), satId...)
+
+	d = Diagnostic{
+		Code:     CodeSatIDRepaired,
+		Severity: SeverityWarning,
+		Sat:      satId,
+		Msg:      fmt.Sprintf("modified invalid satellite '%s '->'%s'", string(bb), satId),
+	}
+	return fixed, d, true
+}
@@ -0,0 +1,183 @@
+package crinex
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// broken epoch records found in the files named in the comments of the rules
+const (
+	recSplitSatList  = "&99  6 12  0 14  0.0000000  0  8 18 14 27 16 4 19 22 15"   // jab11630.99d
+	recExtraSpace    = "&99  8 13  0 20 30.0000000  0  8  07 27 18 04 10 02 19 13" // jab12250.99d
+	recSatIDSpace    = "&98  9  9  0  0  0.0000000  0  2  4 9"                     // alic2520.98d
+	recSatIDSpaceV3  = "> 2023 01 01 00 00  0.0000000  0  2      G01G9"
+	recValidV3       = "> 2023 01 01 00 00  0.0000000  0  3      G01G02R03"
+	recCommaSatList  = "> 2023 01 01 00 00  0.0000000  0  2      G01,G02"
+	recSplitSatListF = "&99  6 12  0 14  0.0000000  0  8 18 14 27 16  4 19 22 15"
+)
+
+func TestRepairRule(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   RepairRule
+		rec    string
+		ver    string
+		numSat int
+		fixed  string // empty if not applicable
+		code   Code
+	}{
+		{"split", splitSatListRule{}, recSplitSatList, "1.0", 8, recSplitSatListF, CodeSatListRepaired},
+		{"split/count", splitSatListRule{}, recSplitSatList, "1.0", 7, "", ""},
+		{"split/version", splitSatListRule{}, recSplitSatList, "2.0", 8, "", ""},
+		{"extra-space", extraSpaceSatListRule{}, recExtraSpace, "1.0", 8,
+			"&99  8 13  0 20 30.0000000  0  8 07 27 18 04 10 02 19 13", CodeSatListRepaired},
+		{"extra-space/other", extraSpaceSatListRule{}, recSplitSatList, "1.0", 8, "", ""},
+		{"satid-space", satIDSpaceRule{}, recSatIDSpace, "1.0", 2,
+			"&98  9  9  0  0  0.0000000  0  2  4  9", CodeSatIDRepaired},
+		{"satid-space/v3", satIDSpaceRule{}, recSatIDSpaceV3, "3.0", 2,
+			"> 2023 01 01 00 00  0.0000000  0  2      G01G 9", CodeSatIDRepaired},
+		{"satid-space/other", satIDSpaceRule{}, recExtraSpace, "1.0", 8, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := []byte(tt.rec)
+			fixed, d, ok := tt.rule.Repair(rec, tt.ver, tt.numSat)
+			if string(rec) != tt.rec {
+				t.Errorf("rec modified: %q", rec)
+			}
+			if ok != (tt.fixed != "") {
+				t.Fatalf("ok = %v, want %v", ok, tt.fixed != "")
+			}
+			if !ok {
+				return
+			}
+			if string(fixed) != tt.fixed {
+				t.Errorf("fixed = %q, want %q", fixed, tt.fixed)
+			}
+			if d.Code != tt.code || d.Severity != SeverityWarning {
+				t.Errorf("diagnostic = %s %s, want %s warning", d.Code, d.Severity, tt.code)
+			}
+		})
+	}
+}
+
+func TestGetSatListWithCorrection(t *testing.T) {
+	tests := []struct {
+		name    string
+		rec     string
+		ver     string
+		satList []string
+		codes   []Code
+	}{
+		{"valid", recValidV3, "3.0", []string{"G01", "G02", "R03"}, nil},
+		{"split", recSplitSatList, "1.0",
+			[]string{" 18", " 14", " 27", " 16", "  4", " 19", " 22", " 15"},
+			[]Code{CodeEpochRecLength, CodeSatListRepaired}},
+		{"extra-space", recExtraSpace, "1.0",
+			[]string{" 07", " 27", " 18", " 04", " 10", " 02", " 19", " 13"},
+			[]Code{CodeEpochRecLength, CodeSatListRepaired}},
+		{"satid-space", recSatIDSpaceV3, "3.0",
+			[]string{"G01", "G 9"},
+			[]Code{CodeEpochRecLength, CodeSatIDRepaired}},
+
+		// repairable by split-satlist and satid-space: the first rule wins
+		{"order", recSatIDSpace, "1.0",
+			[]string{"  4", "  9"},
+			[]Code{CodeEpochRecLength, CodeSatListRepaired}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			satList, warns, err := getSatListWithCorrection([]byte(tt.rec), tt.ver, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(satList, tt.satList) {
+				t.Errorf("satList = %q, want %q", satList, tt.satList)
+			}
+
+			var codes []Code
+			for _, w := range warns {
+				codes = append(codes, w.Code)
+				if w.Pos != 10 || w.Line != tt.rec {
+					t.Errorf("%s: line %d %q, want line 10 %q", w.Code, w.Pos, w.Line, tt.rec)
+				}
+			}
+			if !slices.Equal(codes, tt.codes) {
+				t.Errorf("codes = %v, want %v", codes, tt.codes)
+			}
+		})
+	}
+}
+
+// commaRule repairs satellite lists separated by commas.
+type commaRule struct{}
+
+func (commaRule) Name() string { return "comma" }
+
+func (commaRule) Repair(rec []byte, ver string, numSat int) ([]byte, Diagnostic, bool) {
+	if !bytes.Contains(rec, []byte(",")) {
+		return nil, Diagnostic{}, false
+	}
+	return bytes.ReplaceAll(rec, []byte(","), nil), Diagnostic{Severity: SeverityInfo}, true
+}
+
+func TestRegisterRepairRule(t *testing.T) {
+	repairRulesMu.Lock()
+	saved := slices.Clone(repairRules)
+	repairRulesMu.Unlock()
+	t.Cleanup(func() {
+		repairRulesMu.Lock()
+		repairRules = saved
+		repairRulesMu.Unlock()
+	})
+
+	names := func() (names []string) {
+		for _, r := range RepairRules() {
+			names = append(names, r.Name())
+		}
+		return names
+	}
+
+	// built-in rules first
+	builtin := []string{"split-satlist", "extra-space-satlist", "satid-space"}
+	if got := names(); !slices.Equal(got, builtin) {
+		t.Fatalf("rules = %v, want %v", got, builtin)
+	}
+
+	// not repaired without the rule
+	if satList, _, _ := getSatListWithCorrection([]byte(recCommaSatList), "3.0", 1); slices.Equal(satList, []string{"G01", "G02"}) {
+		t.Fatalf("repaired without the rule: %q", satList)
+	}
+
+	RegisterRepairRule(commaRule{})
+	if got, want := names(), append(builtin, "comma"); !slices.Equal(got, want) {
+		t.Errorf("rules = %v, want %v", got, want)
+	}
+
+	// RepairRules returns a copy
+	rules := RepairRules()
+	rules[0] = commaRule{}
+	if got := RepairRules()[0].Name(); got != builtin[0] {
+		t.Errorf("registry modified through RepairRules: %s", got)
+	}
+
+	satList, warns, err := getSatListWithCorrection([]byte(recCommaSatList), "3.0", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(satList, []string{"G01", "G02"}) {
+		t.Errorf("satList = %q", satList)
+	}
+
+	// the diagnostic of the rule is completed as a warning
+	if len(warns) != 2 {
+		t.Fatalf("warnings = %d, want 2", len(warns))
+	}
+	d := warns[1]
+	if d.Code != CodeSatListRepaired || d.Severity != SeverityWarning || d.Msg != "epoch record repaired by comma" {
+		t.Errorf("diagnostic = %+v", d)
+	}
+}
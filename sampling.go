@@ -0,0 +1,156 @@
+package crinex
+
+import (
+	"fmt"
+	"time"
+)
+
+// ---------------------------------------------------
+// Sampling check
+// ---------------------------------------------------
+
+// samplingWindow is the number of epochs used to infer the interval from the
+// modal spacing when the header has no INTERVAL.
+const samplingWindow = 16
+
+// samplingEpoch is an epoch waiting for the check.
+type samplingEpoch struct {
+	epoch time.Time
+	line  int
+	rec   string
+}
+
+// samplingChecker reports missing epochs and epochs off the sampling grid.
+// Duplicated and backward epochs are reported by checkEpochOrder.
+type samplingChecker struct {
+	interval time.Duration // nominal interval, 0 if not known yet
+	grid     time.Time     // origin of the sampling grid
+	prev     time.Time     // previous epoch checked
+
+	pending []samplingEpoch // epochs until the interval is known
+}
+
+// setHeader sets the interval given by the INTERVAL header. The interval is
+// inferred from the epochs if not given.
+func (c *samplingChecker) setHeader(header []byte) {
+	c.interval, c.grid = 0, time.Time{}
+	if v, ok := headerInterval(headerLines(header)); ok && v > 0 {
+		c.interval = time.Duration(v * float64(time.Second))
+	}
+}
+
+// SamplingInterval returns the nominal interval used by the sampling check:
+// the header INTERVAL, or the modal spacing of the first epochs if not given.
+// It returns 0 if Options.CheckSampling is not set or the interval is not
+// known yet.
+func (s *Scanner) SamplingInterval() time.Duration {
+	if s.sampling == nil {
+		return 0
+	}
+	return s.sampling.interval
+}
+
+// checkSampling checks the spacing of the current epoch if
+// Options.CheckSampling is set. Returns false if the scanning is stopped.
+func (s *Scanner) checkSampling() bool {
+	c := s.sampling
+	if c == nil {
+		return true
+	}
+
+	e := samplingEpoch{epoch: s.epoch, line: s.epochLineNum, rec: s.epochRec.String()}
+	if c.interval > 0 {
+		s.checkSamplingEpoch(e)
+		return s.err == nil
+	}
+
+	c.pending = append(c.pending, e)
+	if len(c.pending) < samplingWindow {
+		return true
+	}
+	return s.flushSampling()
+}
+
+// flushSampling infers the interval from the pending epochs, and checks them.
+// It is also called at the end of the data, so that files shorter than
+// samplingWindow epochs are checked. Returns false if the scanning is stopped.
+func (s *Scanner) flushSampling() bool {
+	c := s.sampling
+	if c == nil || len(c.pending) == 0 {
+		return s.err == nil
+	}
+
+	epochs := make([]time.Time, len(c.pending))
+	for i, e := range c.pending {
+		epochs[i] = e.epoch
+	}
+	c.interval = modalInterval(epochs)
+
+	pending := c.pending
+	c.pending = nil
+	for _, e := range pending {
+		if c.interval == 0 {
+			break
+		}
+		s.checkSamplingEpoch(e)
+		if s.err != nil {
+			return false
+		}
+	}
+	return s.err == nil
+}
+
+// checkSamplingEpoch reports a gap before the epoch and the epoch off the
+// grid. The grid is aligned to the beginning of the day if the interval
+// divides a day, otherwise to the first epoch.
+func (s *Scanner) checkSamplingEpoch(e samplingEpoch) {
+	c := s.sampling
+
+	if c.grid.IsZero() {
+		c.grid = e.epoch
+		if (24*time.Hour)%c.interval == 0 {
+			c.grid = e.epoch.Truncate(24 * time.Hour)
+		}
+	}
+
+	if off := e.epoch.Sub(c.grid) % c.interval; off != 0 {
+		s.report(Diagnostic{
+			Code:     CodeEpochOffGrid,
+			Severity: SeverityInfo,
+			Pos:      e.line,
+			Epoch:    e.epoch,
+			Line:     e.rec,
+			Msg: fmt.Sprintf("epoch off the %v grid by %v: epoch='%s'",
+				c.interval, off, e.epoch.Format(time.RFC3339Nano)),
+		})
+	}
+
+	prev := c.prev
+	if prev.IsZero() || e.epoch.After(prev) {
+		c.prev = e.epoch
+	}
+	if prev.IsZero() {
+		return
+	}
+
+	if n := missingEpochs(e.epoch.Sub(prev), c.interval); n > 0 {
+		s.report(Diagnostic{
+			Code:     CodeEpochGap,
+			Severity: SeverityInfo,
+			Pos:      e.line,
+			Epoch:    e.epoch,
+			Line:     e.rec,
+			Msg: fmt.Sprintf("%d epochs missing: previous='%s', epoch='%s'",
+				n, prev.Format(time.RFC3339Nano), e.epoch.Format(time.RFC3339Nano)),
+		})
+	}
+}
+
+// missingEpochs returns the number of epochs missing in the spacing dt, or 0
+// if dt is not longer than 1.5 times the interval.
+func missingEpochs(dt, interval time.Duration) int {
+	if interval <= 0 || dt <= interval*3/2 {
+		return 0
+	}
+	return int((dt+interval/2)/interval) - 1
+}
@@ -0,0 +1,70 @@
+package crinex
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCheckSampling(t *testing.T) {
+	type diag struct {
+		code Code
+		pos  int
+	}
+	tests := []struct {
+		name     string
+		b        []byte
+		interval time.Duration
+		want     []diag
+	}{
+		{"gap", corrupted(t, "testdata/event_v3.crx", "\n", "\n"), 30 * time.Second,
+			[]diag{{CodeEpochGap, 22}}},
+		{"off-grid", corrupted(t, "testdata/event_v3.crx", "\n                   3\n", "\n                   31\n"), 30 * time.Second,
+			[]diag{{CodeEpochOffGrid, 15}, {CodeEpochGap, 22}}},
+
+		// the interval is inferred from the epochs without INTERVAL
+		{"no-interval", corrupted(t, "testdata/slip_l5.crx", "    30.000                                                  INTERVAL\n", ""), 30 * time.Second,
+			nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScannerWithOptions(bytes.NewReader(tt.b), Options{CheckSampling: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for s.ScanEpoch() {
+			}
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if s.SamplingInterval() != tt.interval {
+				t.Errorf("interval = %v, want %v", s.SamplingInterval(), tt.interval)
+			}
+
+			var got []diag
+			for _, w := range s.Diagnostics {
+				if w.Severity != SeverityInfo {
+					t.Errorf("severity of %s = %s", w.Code, w.Severity)
+				}
+				got = append(got, diag{w.Code, w.Pos})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("diagnostics = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSamplingDisabled(t *testing.T) {
+	s, err := NewScanner(bytes.NewReader(corrupted(t, "testdata/event_v3.crx", "\n", "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s.ScanEpoch() {
+	}
+	if len(s.Warnings) != 0 || s.SamplingInterval() != 0 {
+		t.Errorf("Warnings = %v, interval = %v", s.Warnings, s.SamplingInterval())
+	}
+}
@@ -3,6 +3,7 @@ package crinex
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -29,21 +30,45 @@ type Scanner struct {
 	picoSec  strRecord                // pico-second part of the epoch (CRINEX>=3.1, RINEX>=4.02)
 
 	// real values for easier access
-	epoch   time.Time
-	satList []string // list of satellites in the current epoch
+	epoch     time.Time
+	prevEpoch time.Time // time tag of the previous epoch
+	satList   []string  // list of satellites in the current epoch
+	events    []Event   // special events skipped before the current epoch
+
+	initialized bool // the current epoch begins with an initialization flag
+
+	sampling *samplingChecker // nil unless Options.CheckSampling
+
+	// time system of the file, set on the first call of SysEpoch
+	timeSys TimeSystem
+	leap    int  // GPS time - UTC given by the header
+	leapSet bool // leap is given by the header
 
 	// file reader and scanner
 	r *io.Reader
 	s *bufio.Scanner
 
+	// files in the concatenated stream
+	fileIndex    int  // index of the current file in the stream
+	fileBoundary bool // the current epoch is the first epoch of a new file
+
 	// line number
 	epochLineNum int // line number of the current epoch record
 	clockLineNum int // line number of the current clock record
 	lineNum      int // line number of the current position
 
+	// options
+	opts Options
+
 	// error and warnings
 	err      error
+	trunc    *Truncation // set if the file ends in the middle of an epoch
+	decoded  int64       // bytes of the RINEX decoded, counted if Options.MaxDecodedBytes is set
 	Warnings WarningList
+
+	// Diagnostics is the diagnostics raised while scanning, unless
+	// Options.OnDiagnostic is set. Warnings holds the Warning of each.
+	Diagnostics []Diagnostic
 }
 
 type SatObss struct {
@@ -75,7 +100,13 @@ func (d *SatObsData) StringRINEX() (s string) {
 	return fmt.Sprintf("%14.3f%c%c", d.Data, d.LLI, d.SS)
 }
 
+// NewScanner returns a new Scanner to read from r with the default options.
 func NewScanner(r io.Reader) (*Scanner, error) {
+	return NewScannerWithOptions(r, Options{})
+}
+
+// NewScannerWithOptions returns a new Scanner to read from r configured by opts.
+func NewScannerWithOptions(r io.Reader, opts Options) (*Scanner, error) {
 	var (
 		s     Scanner
 		err   error
@@ -85,10 +116,15 @@ func NewScanner(r io.Reader) (*Scanner, error) {
 	// setup scanner and get the version of Hatanaka RINEX
 	// Note: RINEX header contents have not parsed at this point
 	s.r = &r
-	s.s, s.ver, lines, err = setup(r)
+	s.opts = opts
+	s.s, s.ver, lines, err = setup(r, opts)
 	s.lineNum += lines // first two lines were scanned in setup
 
 	s.obsTypes = make(map[string][]string)
+	s.data = make(map[string]satDataRecord)
+	if opts.CheckSampling {
+		s.sampling = &samplingChecker{}
+	}
 
 	return &s, err
 }
@@ -107,46 +143,135 @@ func (s *Scanner) Scan() bool {
 func (s *Scanner) ParseHeader() (err error) {
 	var (
 		lines int
-		warns WarningList
+		warns []Diagnostic
 	)
 
 	s.obsTypes, s.header, lines, warns, err = scanHeader(s.s)
+	for _, d := range warns {
+		d.Pos += s.lineNum // line number in the file
+		s.report(d)
+	}
 	s.lineNum += lines
-	s.Warnings = append(s.Warnings, warns...)
 
-	return err
+	if s.obsTypes == nil {
+		s.obsTypes = make(map[string][]string)
+	}
+	if err == nil {
+		err = s.opts.checkObsTypes(s.obsTypes, s.lineNum)
+	}
+	if err == nil {
+		s.decoded += int64(len(s.header))
+		err = checkLimit("MaxDecodedBytes", s.decoded, s.opts.MaxDecodedBytes, s.lineNum)
+	}
+
+	switch {
+	case err != nil:
+		// the data block cannot be scanned without the header
+		s.err = fmt.Errorf("failed to parse header: %w", err)
+		return err
+	case s.err != nil:
+		// rejected in the strict mode
+		return s.err
+	}
+
+	s.timeSys = "" // given by the new header
+	if s.sampling != nil {
+		// the epochs of the previous file are checked with its interval
+		if !s.flushSampling() {
+			return s.err
+		}
+		s.sampling.setHeader(s.header)
+	}
+	return nil
 }
 
 // ScanEpoch reads Hatanaka compressed data for an epoch and
 // set decoded values. Returns true if scan is successful.
 // In the case the scan failed, the error is stored in s.err.
-// Returns true for io.EOF.
+//
+// If the file ends in the middle of the data block of an epoch, the epoch is
+// shrunk to the satellites decoded and ScanEpoch returns true. The next call
+// returns false and Err returns a *TruncatedError. See also Truncated.
 func (s *Scanner) ScanEpoch() bool {
+	// scanning was aborted
+	if s.err != nil {
+		return false
+	}
+
+	// the last epoch was truncated
+	if s.trunc != nil {
+		s.err = &TruncatedError{*s.trunc}
+		return false
+	}
+
 	// The header must be scanned header before the data block is scanned
 	if s.header == nil {
 		if err := s.ParseHeader(); err != nil {
-			s.err = fmt.Errorf("failed to parse header: %w", err)
 			return false
 		}
 	}
 
 	// scan next data block and update data
+	s.events = nil
 	if ok := s.Scan(); !ok {
-		s.err = s.s.Err()
+		if s.err = s.s.Err(); s.err == nil {
+			s.flushSampling()
+		}
 		return false
 	}
 	epochStr := s.s.Text()
 
+	// a new file begins in the concatenated stream
+	s.fileBoundary = false
+	for isCRINEXMagic(epochStr) {
+		if err := s.startNewFile(epochStr); err != nil {
+			return false
+		}
+		if ok := s.Scan(); !ok {
+			s.err = s.s.Err()
+			return false
+		}
+		epochStr = s.s.Text()
+	}
+
 RETRY_SCAN_EPOCH:
 	// read a set of data for an epoch.
 	// s will be updated in place.
 	err := s.scanEpoch(epochStr)
+	if s.err != nil {
+		// aborted in OnDiagnostic
+		return false
+	}
+	if errors.Is(err, ErrLimitExceeded) || s.s.Err() != nil {
+		// no way to recover
+		s.err = err
+		return false
+	}
 	if err == io.EOF {
-		return true
+		if s.trunc == nil {
+			// the file ends with a special event
+			s.flushSampling()
+			return false
+		}
+		if s.trunc.Decoded == 0 {
+			// no data decoded for the epoch
+			s.err = &TruncatedError{*s.trunc}
+			return false
+		}
+		return s.checkDecodedBytes() && s.checkEpochOrder() && s.checkSampling()
 	}
 
 	if err != nil {
-		s.Warnings.Add(s.lineNum, fmt.Sprintf("failed to scan epoch: %v", err))
+		s.report(Diagnostic{
+			Code:     CodeInvalidEpoch,
+			Severity: SeverityError,
+			Pos:      s.lineNum,
+			Line:     s.s.Text(),
+			Msg:      fmt.Sprintf("failed to scan epoch: %v", err),
+		})
+		if s.err != nil {
+			return false
+		}
 
 		// seek new epoch record identifier to recover
 
@@ -155,19 +280,69 @@ RETRY_SCAN_EPOCH:
 		// indistinguishable from the the differentiation flag.
 		if i := strings.Index(epochStr, ">"); i > 0 {
 			// found an initialization flag
-			s.Warnings.Add(s.lineNum, fmt.Sprintf("epochrec modified: '%s'", epochStr))
+			s.report(Diagnostic{
+				Code:     CodeEpochRecModified,
+				Severity: SeverityWarning,
+				Pos:      s.lineNum,
+				Line:     epochStr,
+				Msg:      fmt.Sprintf("epochrec modified: '%s'", epochStr),
+			})
+			if s.err != nil {
+				return false
+			}
 
 			epochStr = epochStr[i:]
 			goto RETRY_SCAN_EPOCH
 		}
 
-		// Search for the next initialization flag
-		for s.Scan() {
-			epochStr = s.s.Text()
-			if strings.HasPrefix(epochStr, ">") || strings.HasPrefix(epochStr, "&") {
+		// Search for the next initialization flag or the next file.
+		// The current line where the scan failed can be the head of the next
+		// file, but not the initialization flag.
+		from := s.lineNum
+		for line := s.s.Text(); ; line = s.s.Text() {
+			if isCRINEXMagic(line) {
+				// the current file is interrupted by a new file
+				msg := "resynchronized to the next file"
+				if s.lineNum > from {
+					msg += fmt.Sprintf(", skipped lines %d-%d", from, s.lineNum-1)
+				}
+				s.report(Diagnostic{
+					Code:     CodeResync,
+					Severity: SeverityWarning,
+					Pos:      s.lineNum,
+					Line:     line,
+					Msg:      msg,
+				})
+				if s.err != nil || s.startNewFile(line) != nil {
+					return false
+				}
+				if ok := s.Scan(); !ok {
+					s.err = s.s.Err()
+					return false
+				}
+				epochStr = s.s.Text()
+				goto RETRY_SCAN_EPOCH
+			}
+
+			if s.lineNum > from && (strings.HasPrefix(line, ">") || strings.HasPrefix(line, "&")) {
 				// found initialization flag
+				s.report(Diagnostic{
+					Code:     CodeResync,
+					Severity: SeverityWarning,
+					Pos:      s.lineNum,
+					Line:     line,
+					Msg:      fmt.Sprintf("resynchronized to the initialization flag, skipped lines %d-%d", from, s.lineNum-1),
+				})
+				if s.err != nil {
+					return false
+				}
+				epochStr = line
 				goto RETRY_SCAN_EPOCH
 			}
+
+			if !s.Scan() {
+				break
+			}
 		}
 
 		// recover failed
@@ -175,9 +350,112 @@ RETRY_SCAN_EPOCH:
 		return false
 	}
 
+	return s.checkDecodedBytes() && s.checkEpochOrder() && s.checkSampling()
+}
+
+// checkDecodedBytes counts the bytes of the current epoch in RINEX, and stops
+// the scanning with a *LimitError if the total exceeds MaxDecodedBytes.
+// Returns false if the scanning is stopped.
+func (s *Scanner) checkDecodedBytes() bool {
+	if s.opts.MaxDecodedBytes <= 0 {
+		return true
+	}
+
+	s.decoded += int64(len(s.EventsAsBytes()) + len(s.EpochAsBytes()) + len(s.DataAsBytes()))
+	if err := checkLimit("MaxDecodedBytes", s.decoded, s.opts.MaxDecodedBytes, s.epochLineNum); err != nil {
+		s.err = err
+		return false
+	}
 	return true
 }
 
+// startNewFile parses the header of a new file that begins in the
+// concatenated stream, and resets the obstypes and the decoded data.
+// t is the first line of the new file. On failure, the error is stored in
+// s.err and returned.
+func (s *Scanner) startNewFile(t string) error {
+	ver, err := parseMagic(t)
+	if err != nil {
+		s.err = fmt.Errorf("failed to parse header at line %d: %w", s.lineNum, err)
+		return s.err
+	}
+	s.report(fileBoundaryDiagnostic(s.lineNum, t))
+
+	// skip "CRINEX PROG / DATE"
+	if ok := s.Scan(); !ok {
+		if s.err = s.s.Err(); s.err == nil {
+			s.err = fmt.Errorf("failed to parse header at line %d: %w", s.lineNum, ErrInvalidHeader)
+		}
+		return s.err
+	}
+
+	// reset file information and decoded data
+	s.ver = ver
+	s.header = nil
+	s.epochRec = strRecord{}
+	s.data = make(map[string]satDataRecord)
+	s.clk = diffRecord{}
+	s.picoSec = strRecord{}
+	s.satList = nil
+	s.fileIndex++
+	s.fileBoundary = true
+
+	return s.ParseHeader()
+}
+
+// FileIndex returns the index of the file that the current epoch belongs to
+// in a concatenated stream of files. The index of the first file is 0.
+func (s *Scanner) FileIndex() int {
+	return s.fileIndex
+}
+
+// FileBoundary reports whether the current epoch is the first epoch of a new
+// file in a concatenated stream. Header and ObsTypes return the contents of
+// the new file. FileBoundary is false for the first file.
+func (s *Scanner) FileBoundary() bool {
+	return s.fileBoundary
+}
+
+// Initialized reports whether the current epoch begins with an
+// initialization flag ('>' or '&'), where every differenced record is
+// initialized.
+func (s *Scanner) Initialized() bool {
+	return s.initialized
+}
+
+// checkEpochOrder reports duplicated or non-monotonic epochs.
+// Returns false if the scanning is stopped.
+func (s *Scanner) checkEpochOrder() bool {
+	prev := s.prevEpoch
+	s.prevEpoch = s.epoch
+	if prev.IsZero() {
+		return true
+	}
+
+	switch {
+	case s.epoch.Equal(prev):
+		s.report(Diagnostic{
+			Code:     CodeDuplicateEpoch,
+			Severity: SeverityWarning,
+			Pos:      s.epochLineNum,
+			Epoch:    s.epoch,
+			Line:     s.epochRec.String(),
+			Msg:      fmt.Sprintf("duplicate epoch: epoch='%s'", s.epoch.Format(time.RFC3339Nano)),
+		})
+	case s.epoch.Before(prev):
+		s.report(Diagnostic{
+			Code:     CodeEpochBackward,
+			Severity: SeverityWarning,
+			Pos:      s.epochLineNum,
+			Epoch:    s.epoch,
+			Line:     s.epochRec.String(),
+			Msg:      fmt.Sprintf("epoch goes backward: epoch='%s', previous='%s'", s.epoch.Format(time.RFC3339Nano), prev.Format(time.RFC3339Nano)),
+		})
+	}
+
+	return s.err == nil
+}
+
 // Header returns the header contents for the file
 func (s *Scanner) Header() []byte {
 	return s.header
@@ -193,34 +471,27 @@ func (s *Scanner) SatList() []string {
 	return s.satList
 }
 
-// Epoch returns the time tag for current epoch as time.Time
+// LineNumber returns the line number of the current position in the file.
+func (s *Scanner) LineNumber() int {
+	return s.lineNum
+}
+
+// Epoch returns the time tag for current epoch as time.Time.
+// The time tag is in the time system of the file, not in UTC, while the
+// location of the time.Time is UTC. See SysEpoch for the time system.
 func (s *Scanner) Epoch() time.Time {
 	return s.epoch
 }
 
 func (s *Scanner) EpochAsBytes() []byte {
 	switch s.ver {
-	case "3.0":
-		if s.clk.missing {
-			return []byte(fmt.Sprintf("%-35.35s\n", s.epochRec.StringRINEX()))
-		}
-		return []byte(fmt.Sprintf("%-35.35s      %15.12f\n", s.epochRec.StringRINEX(), s.ClockOffset()))
-
-	case "3.1":
+	case "3.0", "3.1":
 		// CRINEX 3.1 can include pico-second records
-		hasClock := !s.clk.missing
-		picoSecBytes, hasPicoSec := s.PicoSecondsBytes()
-
-		switch {
-		case !hasClock && !hasPicoSec:
-			return []byte(fmt.Sprintf("%-35.35s\n", s.epochRec.StringRINEX()))
-		case hasClock && !hasPicoSec:
-			return []byte(fmt.Sprintf("%-35.35s      %15.12f\n", s.epochRec.StringRINEX(), s.ClockOffset()))
-		case !hasClock && hasPicoSec:
-			return []byte(fmt.Sprintf("%-35.35s                      %5.5s\n", s.epochRec.StringRINEX(), picoSecBytes))
-		default:
-			return []byte(fmt.Sprintf("%-35.35s      %15.12f %5.5s\n", s.epochRec.StringRINEX(), s.ClockOffset(), picoSecBytes))
+		var pico []byte
+		if b, ok := s.PicoSecondsBytes(); ok {
+			pico = b[:]
 		}
+		return s.epochRec.BytesRINEX(s.ClockOffset(), pico)
 	case "1.0":
 		return []byte(s.epochRec.StringRINEXV2(s.ClockOffset()))
 	}
@@ -260,11 +531,8 @@ func (s *Scanner) PicoSeconds() int {
 	case len(s.picoSec.Bytes()) == 0:
 		return missingVal
 
-	// non-numeric entries are format violation
+	// non-numeric entries are format violation, and reported in ScanEpoch
 	case !allBytesAreNumeric(s.picoSec.Bytes()):
-		// warning
-		s.Warnings.Add(s.clockLineNum, fmt.Sprintf("non-numeric entries found in the pico-second record: picoSec='%s'", s.picoSec.String()))
-
 		return missingVal
 	}
 
@@ -284,23 +552,7 @@ func (s *Scanner) PicoSeconds() int {
 // optional record. Negative values and non-numeric entries in the pico-second
 // record will be considered format violations.
 func (s *Scanner) PicoSecondsBytes() (bytes [5]byte, ok bool) {
-	picoSecBytes := s.picoSec.Bytes()
-	if len(picoSecBytes) != 5 {
-		return bytes, false
-	}
-
-	for i, b := range picoSecBytes {
-		if !isNumeric(b) {
-			// warning
-			s.Warnings.Add(s.clockLineNum, fmt.Sprintf("non-numeric entries found in the pico-second record: picoSec='%s'", picoSecBytes))
-
-			return bytes, false
-		}
-		bytes[i] = b
-	}
-
-	// ok
-	return bytes, true
+	return picoSecondsBytes(s.picoSec.Bytes())
 }
 
 // Data returns decompressed RINEX data
@@ -471,15 +723,19 @@ func (s *Scanner) updateEpochRec(epochStr string) error {
 
 		if specialEventFound {
 			// special event found, skip numSkip lines
+			ev := s.newEvent(epochStr)
 			for i := 0; i < numSkip; i++ {
 				if ok := s.Scan(); !ok {
 					err = s.s.Err()
 					if err != nil {
 						return err
 					}
+					s.trunc = &Truncation{EpochLine: ev.Line, Line: s.lineNum, Epoch: ev.Epoch}
 					return io.EOF
 				}
+				ev.Records = append(ev.Records, s.s.Text())
 			}
+			s.events = append(s.events, ev)
 
 			// get new epochStr, and continue to check epochStr
 			if ok := s.Scan(); !ok {
@@ -502,6 +758,7 @@ func (s *Scanner) updateEpochRec(epochStr string) error {
 	}
 
 	// update epochRec
+	s.initialized = initFlagFound
 	if initFlagFound {
 		// initalization flag found and initialize differenciated data
 		s.epochRec.buf = []byte(epochStr)
@@ -561,6 +818,7 @@ func (s *Scanner) scanEpoch(epochStr string) error {
 		if err != nil {
 			return err
 		}
+		s.truncate(s.epochRec.numSatellites(s.ver), 0)
 		return io.EOF
 	}
 	s.clockLineNum = s.lineNum
@@ -572,15 +830,36 @@ func (s *Scanner) scanEpoch(epochStr string) error {
 	if err := s.clk.Decode(clockBytes); err != nil {
 		return err
 	}
+	if err := checkLimit("MaxDiff", int64(s.clk.MaxDiff), int64(s.opts.MaxDiff), s.lineNum); err != nil {
+		return err
+	}
 
 	// if Hatanaka RINEX version >= 3.1, decode the optional pico-second record.
 	if ver >= "3.1" && len(vals) >= 2 {
 		picoSecBytes = vals[1]
-		if err := s.picoSec.Decode(string(picoSecBytes)); err != nil {
+		if string(picoSecBytes) == "&" {
+			// the pico-second record is removed
+			s.picoSec = strRecord{}
+		} else if err := s.picoSec.Decode(string(picoSecBytes)); err != nil {
 			return err
 		}
 	}
 
+	// non-numeric entries in the pico-second record are format violation
+	if b := s.picoSec.Bytes(); len(b) > 0 && (len(b) != 5 || !allBytesAreNumeric(b)) {
+		s.report(Diagnostic{
+			Code:     CodeInvalidPicoSec,
+			Severity: SeverityWarning,
+			Pos:      s.clockLineNum,
+			Epoch:    s.epoch,
+			Line:     s.s.Text(),
+			Msg:      fmt.Sprintf("non-numeric entries found in the pico-second record: picoSec='%s'", b),
+		})
+		if s.err != nil {
+			return s.err
+		}
+	}
+
 	// Update of (3) observation data
 	// Get and update the satellite list for current epoch
 	satList, warns, err := getSatListWithCorrection(s.epochRec.Bytes(), ver, s.epochLineNum)
@@ -588,9 +867,17 @@ func (s *Scanner) scanEpoch(epochStr string) error {
 		return err
 	}
 
+	if err := checkLimit("MaxSatellites", int64(len(satList)), int64(s.opts.MaxSatellites), s.epochLineNum); err != nil {
+		return err
+	}
+
 	s.satList = satList
-	if warns.Len() > 0 {
-		s.Warnings = append(s.Warnings, warns...)
+	for _, d := range warns {
+		d.Epoch = s.epoch
+		s.report(d)
+	}
+	if s.err != nil {
+		return s.err
 	}
 
 	// read data block
@@ -604,7 +891,18 @@ func (s *Scanner) scanEpoch(epochStr string) error {
 			// valid satellite
 			numValidSat++
 		} else {
-			s.Warnings.Add(s.epochLineNum, fmt.Sprintf("ignored invalid satellite: sat='%s'", satId))
+			s.report(Diagnostic{
+				Code:     CodeInvalidSatellite,
+				Severity: SeverityWarning,
+				Pos:      s.epochLineNum,
+				Sat:      satId,
+				Epoch:    s.epoch,
+				Line:     s.epochRec.String(),
+				Msg:      fmt.Sprintf("ignored invalid satellite: sat='%s'", satId),
+			})
+			if s.err != nil {
+				return s.err
+			}
 			continue
 		}
 
@@ -615,9 +913,11 @@ func (s *Scanner) scanEpoch(epochStr string) error {
 				return err
 			}
 
-			// in the case of EOF, finalize the stored data
-			if numValidSat > 0 {
-				s.changeNumSatellites(numValidSat)
+			// in the case of EOF, finalize the stored data.
+			// numValidSat includes the current satellite that is lost.
+			s.truncate(len(s.satList), numValidSat-1)
+			if numValidSat > 1 {
+				s.changeNumSatellites(numValidSat - 1)
 			}
 			return io.EOF
 		}
@@ -634,9 +934,23 @@ func (s *Scanner) scanEpoch(epochStr string) error {
 				// Note that this method can only be used for the initialization line for crinex >= 3.0,
 				// which is usually the case for the first satellite data found in
 				// the file.
-				s.Warnings.Add(s.lineNum, fmt.Sprintf("satsys not included in obstypes found: sat='%s'", satSys))
+				s.report(Diagnostic{
+					Code:     CodeUnknownSatSys,
+					Severity: SeverityWarning,
+					Pos:      s.lineNum,
+					Sat:      satId,
+					Epoch:    s.epoch,
+					Line:     t,
+					Msg:      fmt.Sprintf("satsys not included in obstypes found: sat='%s'", satSys),
+				})
+				if s.err != nil {
+					return s.err
+				}
 
 				n := strings.Count(strings.TrimRight(t, " "), " ") // number of data = number of spaces in the initialization line
+				if err := checkLimit("MaxObsTypes", int64(n), int64(s.opts.MaxObsTypes), s.lineNum); err != nil {
+					return err
+				}
 				s.obsTypes[satSys] = make([]string, n)
 				obsTypes = s.obsTypes
 			default:
@@ -668,6 +982,19 @@ func (s *Scanner) scanEpoch(epochStr string) error {
 			if err := dj.Decode(b); err != nil {
 				return err
 			}
+			if err := checkLimit("MaxDiff", int64(dj.MaxDiff), int64(s.opts.MaxDiff), s.lineNum); err != nil {
+				return err
+			}
+
+			// check the value fits in the RINEX format
+			if !dj.missing && rinexDataOverflow(dj.refData) {
+				d := overflowDiagnostic(s.lineNum, satId, dj.refData)
+				d.Epoch, d.Line = s.epoch, t
+				s.report(d)
+				if s.err != nil {
+					return s.err
+				}
+			}
 
 			// initialize arc
 			if ver == "1.0" && len(b) > 1 && b[1] == '&' {
@@ -697,6 +1024,28 @@ func (s *Scanner) scanEpoch(epochStr string) error {
 	return nil
 }
 
+// truncate records that the file ends in the middle of the current epoch.
+// expected is the number of satellites in the epoch record, and decoded is
+// the number of satellites decoded.
+func (s *Scanner) truncate(expected, decoded int) {
+	s.trunc = &Truncation{
+		EpochLine: s.epochLineNum,
+		Line:      s.lineNum,
+		Epoch:     s.epoch,
+		Expected:  expected,
+		Decoded:   decoded,
+	}
+}
+
+// Truncated reports whether the file ends in the middle of an epoch, and
+// returns the details of the truncated epoch.
+func (s *Scanner) Truncated() (t Truncation, ok bool) {
+	if s.trunc == nil {
+		return t, false
+	}
+	return *s.trunc, true
+}
+
 // changeNumSatellites modifies the number of satellites.
 // This function should only be used to shutdown scanner
 // when the record is interuppted.
@@ -708,28 +1057,80 @@ func (s *Scanner) changeNumSatellites(i int) {
 	if len(s.satList) > i {
 		s.satList = s.satList[:i]
 	}
+	s.epochRec.setNumSatellites(s.ver, i)
+}
 
+// setNumSatellites modifies the number of satellites of the epoch record,
+// and trims the satellite list of CRINEX 1.0.
+// numSatellites returns the number of satellites in the epoch record, or 0
+// if not given.
+func (e *strRecord) numSatellites(ver string) int {
+	i := OFFSET_NUMSAT_V3
+	if ver == "1.0" {
+		i = OFFSET_NUMSAT_V1
+	}
+	if len(e.buf) < i+3 {
+		return 0
+	}
+	n, err := strconv.Atoi(string(bytes.TrimSpace(e.buf[i : i+3])))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func (e *strRecord) setNumSatellites(ver string, i int) {
 	num := []byte(fmt.Sprintf("%3d", i))
 
-	switch s.ver {
+	switch ver {
 	case "3.0", "3.1":
-		if len(s.epochRec.buf) < 35 {
+		if len(e.buf) < 35 {
 			return
 		}
-		s.epochRec.buf[32], s.epochRec.buf[33], s.epochRec.buf[34] = num[0], num[1], num[2]
+		e.buf[32], e.buf[33], e.buf[34] = num[0], num[1], num[2]
 	case "1.0":
-		if len(s.epochRec.buf) < 32 {
+		if len(e.buf) < 32 {
 			return
 		}
-		s.epochRec.buf[29], s.epochRec.buf[30], s.epochRec.buf[31] = num[0], num[1], num[2]
+		e.buf[29], e.buf[30], e.buf[31] = num[0], num[1], num[2]
 
 		// trim satellite list
-		if len(s.epochRec.buf) > 32+3*i {
-			s.epochRec.buf = s.epochRec.buf[:32+3*i]
+		if len(e.buf) > 32+3*i {
+			e.buf = e.buf[:32+3*i]
 		}
 	}
 }
 
+// Err returns the first error encountered by the Scanner.
 func (s *Scanner) Err() error {
 	return s.err
 }
+
+// Abort stops the scanning. Subsequent calls of ScanEpoch return false and
+// Err returns err. Abort is intended to be called from Options.OnDiagnostic.
+func (s *Scanner) Abort(err error) {
+	if err == nil {
+		err = ErrAborted
+	}
+	s.err = err
+}
+
+// report passes a diagnostic to Options.OnDiagnostic if set, otherwise
+// appends it to s.Diagnostics and its Warning to s.Warnings. The diagnostic is
+// also written to Options.Logger.
+// In the strict mode, the scanning is stopped with a *ValidationError if the
+// severity of the diagnostic is SeverityWarning or higher.
+func (s *Scanner) report(d Diagnostic) {
+	logDiagnostic(s.opts.Logger, d)
+
+	if s.opts.OnDiagnostic != nil {
+		s.opts.OnDiagnostic(d)
+	} else {
+		s.Diagnostics = append(s.Diagnostics, d)
+		s.Warnings = append(s.Warnings, d.Warning())
+	}
+
+	if err := s.opts.validate(d); err != nil && s.err == nil {
+		s.err = err
+	}
+}
@@ -0,0 +1,465 @@
+package crinex
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------
+// Cycle slip detection
+// ---------------------------------------------------
+
+// SlipThresholds is the thresholds of the cycle slip tests.
+type SlipThresholds struct {
+	MW float64 // Melbourne-Wübbena: deviation from the arc mean in wide-lane cycles
+	GF float64 // geometry-free: jump between the epochs in meters
+}
+
+// DefaultSlipThresholds is used for the systems without thresholds in
+// SlipOptions.
+var DefaultSlipThresholds = SlipThresholds{MW: 4, GF: 0.05}
+
+// SlipMark is the way MarkSlips writes the cycle slips detected.
+type SlipMark int
+
+const (
+	SlipMarkLLI   SlipMark = iota // set LLI bit 0 of the phase observations
+	SlipMarkEvent                 // write event flag 6 (cycle slip) records
+)
+
+// SlipOptions configures a SlipDetector.
+type SlipOptions struct {
+	// Thresholds of the satellite systems, e.g. "G". DefaultSlipThresholds
+	// is used for the systems not given.
+	Thresholds map[string]SlipThresholds
+
+	// MaxGap is the longest spacing of the observations tested against each
+	// other. The tests restart after a longer gap. If zero, 1.5 times the
+	// header INTERVAL is used, or the gaps are not limited if INTERVAL is
+	// not given.
+	MaxGap time.Duration
+
+	// Mark is the way MarkSlips writes the cycle slips detected.
+	Mark SlipMark
+}
+
+// thresholds returns the thresholds of the satellite system.
+func (o *SlipOptions) thresholds(sys string) SlipThresholds {
+	if t, ok := o.Thresholds[sys]; ok {
+		return t
+	}
+	return DefaultSlipThresholds
+}
+
+// Slip is a cycle slip detected between the previous and the current
+// observation of a pair of phase observations.
+type Slip struct {
+	Sat   string    `json:"sat"`
+	Codes [2]string `json:"codes"` // phase observation codes, e.g. ["L1C", "L2W"]
+	Epoch time.Time `json:"epoch"` // first epoch after the slip
+	Line  int       `json:"line"`  // line number of the epoch record
+
+	MW float64 `json:"mw"` // deviation of Melbourne-Wübbena in wide-lane cycles, NaN if no code
+	GF float64 `json:"gf"` // jump of geometry-free in meters
+
+	// Cycles is the slip in cycles of the phase observations estimated from
+	// MW and GF, NaN if no code.
+	Cycles [2]float64 `json:"cycles"`
+}
+
+// MarshalJSON implements json.Marshaler. MW and Cycles are null if NaN,
+// which encoding/json cannot encode.
+func (s Slip) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Sat    string      `json:"sat"`
+		Codes  [2]string   `json:"codes"`
+		Epoch  time.Time   `json:"epoch"`
+		Line   int         `json:"line"`
+		MW     *float64    `json:"mw"`
+		GF     float64     `json:"gf"`
+		Cycles [2]*float64 `json:"cycles"`
+	}{
+		s.Sat, s.Codes, s.Epoch, s.Line,
+		nullNaN(s.MW), s.GF, [2]*float64{nullNaN(s.Cycles[0]), nullNaN(s.Cycles[1])},
+	})
+}
+
+// nullNaN returns nil if v is NaN, otherwise a pointer to v.
+func nullNaN(v float64) *float64 {
+	if math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
+
+// slipBands is the preferred pairs of the frequency bands of the systems.
+var slipBands = map[string][][2]byte{
+	"G": {{'1', '2'}, {'1', '5'}},
+	"R": {{'1', '2'}},
+	"E": {{'1', '5'}, {'1', '7'}},
+	"C": {{'2', '7'}, {'2', '6'}, {'1', '5'}},
+	"J": {{'1', '2'}, {'1', '5'}},
+	"I": {{'5', '9'}},
+	"S": {{'1', '5'}},
+}
+
+// slipKey identifies the tests of a satellite and a pair of phase codes.
+type slipKey struct {
+	sat   string
+	codes [2]string
+}
+
+// slipState holds the tests of a pair of phase observations.
+type slipState struct {
+	last   time.Time // last epoch tested
+	mwMean float64   // mean of MW over the arc, NaN if no code
+	mwN    int
+	gf     float64 // last GF
+}
+
+// SlipDetector detects cycle slips epoch by epoch with the Melbourne-Wübbena
+// wide-lane (MW) and the geometry-free (GF) combinations of dual-frequency
+// phase and code observations. Every pair of the bands observed is tested,
+// e.g. L1/L2 and L1/L5 for GPS, so that a slip of L5 is detected even if L1
+// and L2 are continuous. Slips flagged by the receiver (LLI bit 0), gaps
+// longer than MaxGap and the epoch flag 1 (power failure) restart the tests
+// without reporting a slip.
+type SlipDetector struct {
+	opts   SlipOptions
+	freq   FreqTable
+	maxGap time.Duration
+	state  map[slipKey]*slipState
+
+	started bool
+}
+
+// NewSlipDetector returns a new SlipDetector.
+func NewSlipDetector(opts SlipOptions) *SlipDetector {
+	return &SlipDetector{opts: opts, state: make(map[slipKey]*slipState)}
+}
+
+// Update tests the current epoch of s, and returns the slips detected.
+func (d *SlipDetector) Update(s *Scanner) []Slip {
+	return d.update(s, s.Data())
+}
+
+// update tests the observations obs of the current epoch of s.
+func (d *SlipDetector) update(s *Scanner, obs []SatObss) (slips []Slip) {
+	if !d.started || s.FileBoundary() {
+		d.started = true
+		d.freq = NewFreqTable(s.Header())
+		d.maxGap = maxGapOf(s.Header(), d.opts.MaxGap)
+	}
+	if s.EpochFlag() == 1 || s.FileBoundary() {
+		d.state = make(map[slipKey]*slipState)
+	}
+
+	for _, o := range obs {
+		if len(o.ObsData) == 0 {
+			continue
+		}
+		sys := o.SatId[:1]
+		if sys == " " {
+			sys = "G"
+		}
+
+		codes := s.ObsTypes()[o.SatId[:1]]
+		for _, p := range d.pairs(o, codes, sys) {
+			if slip, ok := d.test(s, o.SatId, sys, codes, p); ok {
+				slips = append(slips, slip)
+			}
+		}
+	}
+	return slips
+}
+
+// test tests the pair p of the observations of the satellite sat, and returns
+// the slip if detected.
+func (d *SlipDetector) test(s *Scanner, sat, sys string, codes []string, p slipPair) (slip Slip, found bool) {
+	epoch := s.Epoch()
+	key := slipKey{sat, [2]string{codes[p.phase[0]], codes[p.phase[1]]}}
+	st, ok := d.state[key]
+	if !ok || p.lostLock || (d.maxGap > 0 && epoch.Sub(st.last) > d.maxGap) {
+		// (re)start the tests
+		d.state[key] = &slipState{last: epoch, mwMean: p.mw, mwN: 1, gf: p.gf}
+		return slip, false
+	}
+
+	th := d.opts.thresholds(sys)
+	dmw := p.mw - st.mwMean
+	dgf := p.gf - st.gf
+	if math.Abs(dgf) > th.GF || math.Abs(dmw) > th.MW {
+		slip = Slip{
+			Sat:    sat,
+			Codes:  key.codes,
+			Epoch:  epoch,
+			Line:   s.epochLineNum,
+			MW:     dmw,
+			GF:     dgf,
+			Cycles: [2]float64{math.NaN(), math.NaN()},
+		}
+		if !math.IsNaN(dmw) {
+			// dGF = l1 dN1 - l2 dN2, dNw = dN1 - dN2
+			nw := math.Round(dmw)
+			n1 := math.Round((dgf - p.lambda[1]*nw) / (p.lambda[0] - p.lambda[1]))
+			slip.Cycles = [2]float64{n1, n1 - nw}
+		}
+		d.state[key] = &slipState{last: epoch, mwMean: p.mw, mwN: 1, gf: p.gf}
+		return slip, true
+	}
+
+	st.last = epoch
+	st.gf = p.gf
+	if !math.IsNaN(p.mw) {
+		if math.IsNaN(st.mwMean) {
+			st.mwMean, st.mwN = p.mw, 1
+		} else {
+			st.mwN++
+			st.mwMean += (p.mw - st.mwMean) / float64(st.mwN)
+		}
+	}
+	return slip, false
+}
+
+// slipPair is a pair of the observations of a satellite at an epoch.
+type slipPair struct {
+	phase    [2]int     // indices of the phase observations
+	lambda   [2]float64 // wavelengths
+	mw       float64    // MW in wide-lane cycles, NaN if no code
+	gf       float64    // GF in meters
+	lostLock bool       // LLI bit 0 set to either phase
+}
+
+// pairs returns the pairs of the preferred bands with both phases observed,
+// and the combinations.
+func (d *SlipDetector) pairs(o SatObss, codes []string, sys string) (pairs []slipPair) {
+	for _, bands := range slipBands[sys] {
+		var (
+			p    slipPair
+			code [2]int
+			freq [2]float64
+		)
+		ok := true
+		for k, band := range bands {
+			p.phase[k], code[k] = signalIndices(o, codes, band)
+			if p.phase[k] < 0 {
+				ok = false
+				break
+			}
+			if freq[k], ok = d.freq.Frequency(o.SatId, codes[p.phase[k]]); !ok {
+				break
+			}
+			p.lambda[k] = SpeedOfLight / freq[k]
+		}
+		if !ok {
+			continue
+		}
+
+		l1, l2 := o.ObsData[p.phase[0]], o.ObsData[p.phase[1]]
+		ll1, _ := l1.LossOfLock()
+		ll2, _ := l2.LossOfLock()
+		p.lostLock = ll1.LostLock() || ll2.LostLock()
+		p.gf = p.lambda[0]*l1.Data - p.lambda[1]*l2.Data
+
+		p.mw = math.NaN()
+		if code[0] >= 0 && code[1] >= 0 {
+			f1, f2 := freq[0], freq[1]
+			lw := SpeedOfLight / (f1 - f2)
+			pn := (f1*o.ObsData[code[0]].Data + f2*o.ObsData[code[1]].Data) / (f1 + f2)
+			p.mw = (l1.Data - l2.Data) - pn/lw
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs
+}
+
+// signalIndices returns the index of the first phase observation of the
+// band observed, and the index of the code observation of the same signal
+// (or of the band) observed. The indices are -1 if not found.
+func signalIndices(o SatObss, codes []string, band byte) (phase, code int) {
+	observed := func(j int) bool {
+		return j < len(o.ObsData) && !math.IsNaN(o.ObsData[j].Data)
+	}
+
+	phase, code = -1, -1
+	for j, c := range codes {
+		if len(c) >= 2 && c[0] == 'L' && c[1] == band && observed(j) {
+			phase = j
+			break
+		}
+	}
+	if phase < 0 {
+		return -1, -1
+	}
+
+	// the code of the same attribute, e.g. "C1C" for "L1C"
+	if attr := codes[phase][2:]; attr != "" {
+		if j := slices.Index(codes, "C"+string(band)+attr); j >= 0 && observed(j) {
+			return phase, j
+		}
+	}
+	for j, c := range codes {
+		if len(c) >= 2 && (c[0] == 'C' || c[0] == 'P') && c[1] == band && observed(j) {
+			return phase, j
+		}
+	}
+	return phase, -1
+}
+
+// MarkSlips decodes the Hatanaka RINEX file read from r, detects the cycle
+// slips, and writes the data to w in RINEX with the slips marked as given by
+// opts.Mark:
+//
+//   - SlipMarkLLI sets LLI bit 0 of both phase observations of the slip.
+//   - SlipMarkEvent writes an event flag 6 record before the epoch, with the
+//     estimated slips in cycles in the columns of the phase observations.
+//
+// The special events of the input and the headers of the concatenated files
+// are written as in Convert.
+func MarkSlips(w io.Writer, r io.Reader, opts SlipOptions) (slips []Slip, err error) {
+	s, err := NewScanner(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ParseHeader(); err != nil {
+		return nil, err
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(s.Header()); err != nil {
+		return nil, err
+	}
+
+	d := NewSlipDetector(opts)
+	for s.ScanEpoch() {
+		if s.FileBoundary() {
+			// header of the next file in the concatenated stream
+			if _, err := bw.Write(s.Header()); err != nil {
+				return slips, err
+			}
+		}
+		if _, err := bw.Write(s.EventsAsBytes()); err != nil {
+			return slips, err
+		}
+
+		obs := s.Data()
+		found := d.update(s, obs)
+		slips = append(slips, found...)
+
+		if len(found) > 0 && opts.Mark == SlipMarkEvent {
+			if _, err := bw.Write(slipEvent(s, found)); err != nil {
+				return slips, err
+			}
+		}
+		if len(found) > 0 && opts.Mark == SlipMarkLLI {
+			markLLI(s, obs, found)
+		}
+
+		if _, err := bw.Write(s.EpochAsBytes()); err != nil {
+			return slips, err
+		}
+		for _, o := range obs {
+			if len(o.ObsData) == 0 {
+				continue
+			}
+			if _, err := bw.Write(formatSatObss(s.ver, o)); err != nil {
+				return slips, err
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		bw.Flush()
+		return slips, err
+	}
+
+	// special events at the end of the file
+	if _, err := bw.Write(s.EventsAsBytes()); err != nil {
+		return slips, err
+	}
+	return slips, bw.Flush()
+}
+
+// markLLI sets LLI bit 0 of the phase observations of the slips.
+func markLLI(s *Scanner, obs []SatObss, slips []Slip) {
+	for _, slip := range slips {
+		i := slices.IndexFunc(obs, func(o SatObss) bool { return o.SatId == slip.Sat })
+		if i < 0 {
+			continue
+		}
+		codes := s.ObsTypes()[slip.Sat[:1]]
+		for _, c := range slip.Codes {
+			j := slices.Index(codes, c)
+			if j < 0 || j >= len(obs[i].ObsData) {
+				continue
+			}
+			d := &obs[i].ObsData[j]
+			l, _ := d.LossOfLock()
+			d.LLI = '0' + byte(l|LLILostLock)
+		}
+	}
+}
+
+// slipEvent returns the event flag 6 (cycle slip) record of the slips at the
+// current epoch of s.
+func slipEvent(s *Scanner, slips []Slip) []byte {
+	// slips of the satellites in the order of the epoch
+	bySat := make(map[string][]Slip)
+	var sats []string
+	for _, slip := range slips {
+		if _, ok := bySat[slip.Sat]; !ok {
+			sats = append(sats, slip.Sat)
+		}
+		bySat[slip.Sat] = append(bySat[slip.Sat], slip)
+	}
+	sort.SliceStable(sats, func(i, j int) bool {
+		return slices.Index(s.satList, sats[i]) < slices.Index(s.satList, sats[j])
+	})
+
+	buf := []byte(eventRecord(s.ver, s.Epoch(), 6, sats))
+	for _, sat := range sats {
+		codes := s.ObsTypes()[sat[:1]]
+		o := SatObss{SatId: sat, ObsData: make([]SatObsData, len(codes))}
+		for j := range o.ObsData {
+			o.ObsData[j] = SatObsData{Data: math.NaN(), LLI: ' ', SS: ' '}
+		}
+		for _, slip := range bySat[sat] {
+			for k, c := range slip.Codes {
+				if j := slices.Index(codes, c); j >= 0 {
+					o.ObsData[j].Data = slip.Cycles[k]
+				}
+			}
+		}
+		buf = append(buf, formatSatObss(s.ver, o)...)
+	}
+	return buf
+}
+
+// eventRecord returns the RINEX epoch record of an event with the flag for
+// the satellites.
+func eventRecord(ver string, t time.Time, flag int, sats []string) string {
+	sec := float64(t.Second()) + float64(t.Nanosecond())*1e-9
+	if ver != "1.0" {
+		return fmt.Sprintf("> %4d %02d %02d %02d %02d%11.7f  %d%3d\n",
+			t.Year(), int(t.Month()), t.Day(), t.Hour(), t.Minute(), sec, flag, len(sats))
+	}
+
+	// RINEX 2: the satellite list of 12 satellites per line
+	var b strings.Builder
+	fmt.Fprintf(&b, " %02d %2d %2d %2d %2d%11.7f  %d%3d",
+		t.Year()%100, int(t.Month()), t.Day(), t.Hour(), t.Minute(), sec, flag, len(sats))
+	for i, sat := range sats {
+		if i > 0 && i%12 == 0 {
+			b.WriteString("\n" + strings.Repeat(" ", 32))
+		}
+		b.WriteString(sat)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,166 @@
+package crinex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+// detectSlips returns the slips detected in the CRINEX file.
+func detectSlips(t *testing.T, name string, opts SlipOptions) (slips []Slip) {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s, err := NewScanner(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ParseHeader(); err != nil {
+		t.Fatal(err)
+	}
+	d := NewSlipDetector(opts)
+	for s.ScanEpoch() {
+		slips = append(slips, d.Update(s)...)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return slips
+}
+
+func TestSlipDetectorL5(t *testing.T) {
+	// slip_l5.crx: a slip of 10 cycles of L5Q of G01 at 00:06:00, and
+	// continuous L1C and L2W
+	slips := detectSlips(t, "testdata/slip_l5.crx", SlipOptions{})
+	if len(slips) != 1 {
+		t.Fatalf("slips = %+v, want 1", slips)
+	}
+
+	s := slips[0]
+	if s.Sat != "G01" || s.Codes != [2]string{"L1C", "L5Q"} || !s.Epoch.Equal(time.Date(2023, 1, 1, 0, 6, 0, 0, time.UTC)) || s.Line != 57 {
+		t.Errorf("slip = %+v", s)
+	}
+	if s.Cycles != [2]float64{0, 10} {
+		t.Errorf("cycles = %v, want [0 10]", s.Cycles)
+	}
+}
+
+// markSlips returns the output of MarkSlips for the CRINEX file.
+func markSlips(t *testing.T, name string, opts SlipOptions) ([]byte, []Slip) {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var out bytes.Buffer
+	slips, err := MarkSlips(&out, f, opts)
+	if err != nil {
+		t.Fatalf("MarkSlips: %v", err)
+	}
+	return out.Bytes(), slips
+}
+
+func TestMarkSlips(t *testing.T) {
+	const epoch = "> 2023 01 01 00 06  0.0000000  0  2\n"
+	want := readerOutput(t, "testdata/slip_l5.crx")
+	i := bytes.Index(want, []byte(epoch))
+	if i < 0 {
+		t.Fatalf("%q not found", epoch)
+	}
+
+	t.Run("lli", func(t *testing.T) {
+		out, slips := markSlips(t, "testdata/slip_l5.crx", SlipOptions{Mark: SlipMarkLLI})
+		if len(slips) != 1 {
+			t.Fatalf("slips = %+v, want 1", slips)
+		}
+
+		// LLI bit 0 of L1C and L5Q of G01, the first satellite of the epoch
+		g01 := i + len(epoch)
+		lli := []int{g01 + 3 + 16*1 + 14, g01 + 3 + 16*5 + 14}
+		for _, j := range lli {
+			if j >= len(out) || out[j] != '1' {
+				t.Fatalf("LLI at %d not set:\n%s", j, out[g01:])
+			}
+		}
+
+		// the same output as NewReader except for the LLIs
+		got := bytes.Clone(out)
+		for _, j := range lli {
+			got[j] = ' '
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("MarkSlips and NewReader differ:\n%s\nwant:\n%s", out, want)
+		}
+	})
+
+	t.Run("event", func(t *testing.T) {
+		out, _ := markSlips(t, "testdata/slip_l5.crx", SlipOptions{Mark: SlipMarkEvent})
+
+		// the event record of the slip in cycles before the epoch
+		ev := "> 2023 01 01 00 06  0.0000000  6  1\n" +
+			"G01                         0.000                                                          10.000\n"
+		if got := append(append(bytes.Clone(want[:i]), ev...), want[i:]...); !bytes.Equal(out, got) {
+			t.Errorf("output:\n%s\nwant:\n%s", out, got)
+		}
+	})
+
+	t.Run("special-events", func(t *testing.T) {
+		out, slips := markSlips(t, "testdata/event_v3.crx", SlipOptions{})
+		if len(slips) != 0 {
+			t.Errorf("slips = %+v, want none", slips)
+		}
+		if want := readerOutput(t, "testdata/event_v3.crx"); !bytes.Equal(out, want) {
+			t.Errorf("MarkSlips and NewReader differ:\n%s\nwant:\n%s", out, want)
+		}
+	})
+}
+
+func TestMarkSlipsWriteError(t *testing.T) {
+	f, err := os.Open("testdata/slip_l5.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := MarkSlips(errWriter{}, f, SlipOptions{}); !errors.Is(err, errWrite) {
+		t.Errorf("err = %v, want %v", err, errWrite)
+	}
+}
+
+func TestSlipJSON(t *testing.T) {
+	epoch := time.Date(2023, 1, 1, 0, 5, 0, 0, time.UTC)
+	tests := []struct {
+		slip Slip
+		want string
+	}{
+		{
+			Slip{Sat: "G01", Codes: [2]string{"L1C", "L5Q"}, Epoch: epoch, Line: 40, MW: 12.5, GF: 0.25, Cycles: [2]float64{10, 12}},
+			`{"sat":"G01","codes":["L1C","L5Q"],"epoch":"2023-01-01T00:05:00Z","line":40,"mw":12.5,"gf":0.25,"cycles":[10,12]}`,
+		},
+		{
+			// without the codes
+			Slip{Sat: "G01", Codes: [2]string{"L1C", "L5Q"}, Epoch: epoch, Line: 40, MW: math.NaN(), GF: 0.25, Cycles: [2]float64{math.NaN(), math.NaN()}},
+			`{"sat":"G01","codes":["L1C","L5Q"],"epoch":"2023-01-01T00:05:00Z","line":40,"mw":null,"gf":0.25,"cycles":[null,null]}`,
+		},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.slip)
+		if err != nil {
+			t.Errorf("Marshal(%+v): %v", tt.slip, err)
+			continue
+		}
+		if string(b) != tt.want {
+			t.Errorf("Marshal(%+v) = %s, want %s", tt.slip, b, tt.want)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package crinex
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------
+// Splicing consecutive files
+// ---------------------------------------------------
+
+// SpliceOptions configures Splice.
+type SpliceOptions struct {
+	// CRINEX writes the output in Hatanaka RINEX instead of RINEX.
+	CRINEX bool
+
+	// Open opens the input of the name. The inputs are opened with os.Open
+	// if nil. Every input is opened twice, see Splice.
+	Open func(name string) (io.ReadCloser, error)
+}
+
+// Splice merges consecutive Hatanaka RINEX files of one station, opened by
+// the names with opts.Open, and writes a single RINEX file to out.
+//
+// Inputs must be given in time order. Epochs that are not later than the last
+// epoch already written, e.g. the overlap of hourly files, are dropped.
+// If the obstypes differ between the inputs, the header declares the union of
+// the obstypes and the data columns are remapped, with missing values for the
+// obstypes not observed. TIME OF FIRST OBS and TIME OF LAST OBS are rewritten
+// to the first and last epochs written, and the other header lines are taken
+// from the first input. Special events are written before the epochs they
+// precede, and dropped with the overlapping epochs.
+//
+// Inputs of different RINEX major versions or different marker names are
+// rejected with ErrIncompatible. Since the header is given by all the inputs,
+// the inputs are decoded twice: the first pass reads the headers and the
+// epochs, and the second pass writes the data.
+func Splice(out io.Writer, names []string, opts SpliceOptions) error {
+	if len(names) == 0 {
+		return fmt.Errorf("%w: no input", ErrIncompatible)
+	}
+	if opts.Open == nil {
+		opts.Open = func(name string) (io.ReadCloser, error) { return os.Open(name) }
+	}
+
+	// first pass: the header
+	var sp splicer
+	for _, name := range names {
+		if err := scanInput(name, opts.Open, sp.scan); err != nil {
+			return err
+		}
+	}
+	if sp.numEpochs == 0 {
+		return fmt.Errorf("%w: no epoch found", ErrIncompatible)
+	}
+
+	bw := bufio.NewWriter(out)
+	header := sp.header()
+	if opts.CRINEX {
+		sp.enc = newEncoder(sp.ver)
+		header = sp.enc.crinexHeader(header, time.Now())
+	}
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	// second pass: the data
+	for _, name := range names {
+		err := scanInput(name, opts.Open, func(s *Scanner) error { return sp.write(bw, s) })
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// scanInput opens the input of the name, parses the header and passes the
+// Scanner to fn.
+func scanInput(name string, open func(string) (io.ReadCloser, error), fn func(s *Scanner) error) error {
+	f, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := NewScanner(f)
+	if err == nil {
+		err = s.ParseHeader()
+	}
+	if err == nil {
+		err = fn(s)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// splicer holds the state of Splice.
+type splicer struct {
+	ver        string   // CRINEX version of the first input
+	lines      []string // header lines of the first input
+	markerName string
+	timeSys    string // time system of TIME OF FIRST OBS
+
+	// union of the obstypes, and the satellite systems in the header order
+	obsTypes map[string][]string
+	systems  []string
+
+	first, last time.Time
+	numEpochs   int
+
+	// second pass
+	enc     *encoder // nil for RINEX
+	written time.Time
+	started bool
+}
+
+// scan decodes the input in the first pass, and merges the header and the
+// epochs.
+func (sp *splicer) scan(s *Scanner) error {
+	if err := sp.addHeader(s); err != nil {
+		return err
+	}
+
+	for s.ScanEpoch() {
+		if s.FileBoundary() {
+			// concatenated file in the input
+			if err := sp.addHeader(s); err != nil {
+				return err
+			}
+		}
+
+		if sp.numEpochs > 0 && !s.Epoch().After(sp.last) {
+			// overlap
+			continue
+		}
+		if sp.numEpochs == 0 {
+			sp.first = s.Epoch()
+		}
+		sp.last = s.Epoch()
+		sp.numEpochs++
+	}
+	return s.Err()
+}
+
+// write decodes the input in the second pass, and writes the epochs with the
+// data arranged in the union obstypes to w.
+func (sp *splicer) write(w io.Writer, s *Scanner) error {
+	// column indexes of the obstypes of s in the union obstypes
+	columns := sp.columns(s)
+
+	for s.ScanEpoch() {
+		if s.FileBoundary() {
+			columns = sp.columns(s)
+		}
+
+		if sp.started && !s.Epoch().After(sp.written) {
+			// overlap, dropped with the special events
+			continue
+		}
+		sp.started = true
+		sp.written = s.Epoch()
+
+		data := make([]SatObss, 0, len(s.Data()))
+		for _, o := range s.Data() {
+			data = append(data, sp.remap(o, columns))
+		}
+
+		var buf []byte
+		if sp.enc != nil {
+			buf = append(sp.enc.events(s), sp.enc.encodeObs(s, data)...)
+		} else {
+			buf = append(s.EventsAsBytes(), s.EpochAsBytes()...)
+			for _, o := range data {
+				buf = append(buf, formatSatObss(sp.ver, o)...)
+			}
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	// special events at the end of the input
+	ev := s.EventsAsBytes()
+	if sp.enc != nil {
+		ev = sp.enc.events(s)
+	}
+	_, err := w.Write(ev)
+	return err
+}
+
+// addHeader checks the header of s against the first one, and merges the
+// obstypes into the union obstypes.
+func (sp *splicer) addHeader(s *Scanner) error {
+	lines := headerLines(s.Header())
+	markerName := ""
+	if l, ok := findHeaderLine(lines, "MARKER NAME"); ok {
+		markerName = trimHeaderValue(l, 0, 60)
+	}
+
+	if sp.lines == nil {
+		// first input
+		sp.ver = s.ver
+		sp.lines = lines
+		sp.markerName = markerName
+		sp.obsTypes = make(map[string][]string)
+		sp.systems = obsTypesOrder(lines)
+		if l, ok := findHeaderLine(lines, labelFirstObs); ok {
+			_, sp.timeSys, _ = parseObsTime(l)
+		}
+	}
+
+	if (sp.ver == "1.0") != (s.ver == "1.0") {
+		return fmt.Errorf("%w: RINEX version differs from the first input", ErrIncompatible)
+	}
+	if !strings.EqualFold(markerName, sp.markerName) {
+		return fmt.Errorf("%w: marker name '%s' differs from '%s'", ErrIncompatible, markerName, sp.markerName)
+	}
+
+	// merge obstypes, keeping the order of the first appearance
+	for _, sys := range obsTypesOrder(lines) {
+		if !slices.Contains(sp.systems, sys) {
+			sp.systems = append(sp.systems, sys)
+		}
+	}
+	for sys, codes := range s.ObsTypes() {
+		for _, c := range codes {
+			if !slices.Contains(sp.obsTypes[sys], c) {
+				sp.obsTypes[sys] = append(sp.obsTypes[sys], c)
+			}
+		}
+	}
+	return nil
+}
+
+// columns returns the column indexes of the obstypes of s in the union
+// obstypes for every satellite system.
+func (sp *splicer) columns(s *Scanner) map[string][]int {
+	columns := make(map[string][]int)
+	for sys, codes := range s.ObsTypes() {
+		idx := make([]int, len(codes))
+		for i, c := range codes {
+			idx[i] = slices.Index(sp.obsTypes[sys], c)
+		}
+		columns[sys] = idx
+	}
+	return columns
+}
+
+// remap returns the observables arranged in the union obstypes.
+func (sp *splicer) remap(o SatObss, columns map[string][]int) SatObss {
+	sys := o.SatId[:1]
+	idx, ok := columns[sys]
+	if !ok || len(idx) != len(o.ObsData) {
+		// satellite system not declared in the header
+		return o
+	}
+
+	r := SatObss{SatId: o.SatId, ObsData: make([]SatObsData, len(sp.obsTypes[sys]))}
+	for i := range r.ObsData {
+		r.ObsData[i] = SatObsData{Data: math.NaN(), LLI: ' ', SS: ' '}
+	}
+	for i, j := range idx {
+		if j >= 0 {
+			r.ObsData[j] = o.ObsData[i]
+		}
+	}
+	return r
+}
+
+// header returns the header with the union obstypes and the time of the first
+// and last observations.
+func (sp *splicer) header() []byte {
+	lines := sp.lines
+
+	if sp.ver == "1.0" {
+		lines = replaceHeaderLines(lines, labelObsTypesV2, "", formatObsTypesV2(sp.obsTypes["G"]))
+	} else {
+		lines = replaceHeaderLines(lines, labelObsTypesV3, "", formatObsTypesV3(sp.obsTypes, sp.systems))
+	}
+
+	timeSys := sp.timeSys
+	if timeSys == "" && sp.ver != "1.0" {
+		timeSys = "GPS"
+	}
+	lines = replaceHeaderLines(lines, labelFirstObs, labelEndOfHeader,
+		[]string{formatObsTime(sp.first, timeSys, labelFirstObs)})
+	lines = replaceHeaderLines(lines, labelLastObs, labelEndOfHeader,
+		[]string{formatObsTime(sp.last, timeSys, labelLastObs)})
+
+	return joinHeaderLines(lines)
+}
+
+// formatSatObss returns the observables of a satellite as RINEX lines for the
+// CRINEX version ver. The output is the same as Scanner.DataAsBytes.
+func formatSatObss(ver string, o SatObss) (buf []byte) {
+	var line []byte
+	if ver != "1.0" {
+		line = append(line, fmt.Sprintf("%3.3s", o.SatId)...)
+	}
+
+	for k, d := range o.ObsData {
+		if math.IsNaN(d.Data) {
+			line = append(line, "                "...)
+		} else {
+			line = append(line, intToRinexDataBytes(int64(math.Round(d.Data*1000)))...)
+			line = append(line, d.LLI, d.SS)
+		}
+
+		// RINEX 2 has 5 observables per line
+		if ver == "1.0" && (k == len(o.ObsData)-1 || (k+1)%5 == 0) {
+			buf = append(buf, bytes.TrimRight(line, " ")...)
+			buf = append(buf, '\n')
+			line = line[:0]
+		}
+	}
+	if ver != "1.0" {
+		buf = append(buf, bytes.TrimRight(line, " ")...)
+		buf = append(buf, '\n')
+	}
+	return
+}
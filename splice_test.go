@@ -0,0 +1,143 @@
+package crinex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"testing"
+)
+
+// spliced returns the output of Splice of the inputs in RINEX, decoded by
+// NewReader if written in CRINEX.
+func spliced(t *testing.T, inputs [][]byte, crinex bool) []byte {
+	t.Helper()
+	var (
+		names  []string
+		opened = make(map[string]int)
+	)
+	for i := range inputs {
+		names = append(names, fmt.Sprintf("input%d", i))
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		i := slices.Index(names, name)
+		if i < 0 {
+			return nil, os.ErrNotExist
+		}
+		opened[name]++
+		return io.NopCloser(bytes.NewReader(inputs[i])), nil
+	}
+
+	var out bytes.Buffer
+	if err := Splice(&out, names, SpliceOptions{CRINEX: crinex, Open: open}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if opened[name] != 2 {
+			t.Errorf("%s opened %d times, want 2", name, opened[name])
+		}
+	}
+	if crinex {
+		return readerOutputBytes(t, out.Bytes())
+	}
+	return out.Bytes()
+}
+
+func TestSpliceObsTypesUnion(t *testing.T) {
+	// the first input has 4 obstypes and the second has 6, which need
+	// continuation lines in RINEX 2 for the epochs of both inputs
+	var inputs [][]byte
+	for _, name := range []string{"testdata/example_v1.crx", "testdata/splice_v1.crx"} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, b)
+	}
+
+	want, err := os.ReadFile("testdata/splice_v1.rnx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, crinex := range []bool{false, true} {
+		if out := spliced(t, inputs, crinex); !bytes.Equal(out, want) {
+			t.Errorf("CRINEX=%v: output:\n%s\nwant:\n%s", crinex, out, want)
+		}
+	}
+}
+
+// body returns the RINEX after END OF HEADER.
+func body(t *testing.T, b []byte) []byte {
+	t.Helper()
+	i := bytes.Index(b, []byte("END OF HEADER\n"))
+	if i < 0 {
+		t.Fatalf("END OF HEADER not found in:\n%s", b)
+	}
+	return b[i+len("END OF HEADER\n"):]
+}
+
+func TestSpliceEvents(t *testing.T) {
+	full, err := os.ReadFile("testdata/event_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var converted bytes.Buffer
+	if _, _, err := Convert(&converted, bytes.NewReader(full)); err != nil {
+		t.Fatal(err)
+	}
+
+	example, err := os.ReadFile("testdata/example_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exampleConverted bytes.Buffer
+	if _, _, err := Convert(&exampleConverted, bytes.NewReader(example)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		inputs [][]byte
+		want   []byte // RINEX after END OF HEADER
+	}{
+		// the event is carried through
+		{"single", [][]byte{full}, body(t, converted.Bytes())},
+
+		// the event follows the overlap with the first epoch
+		{"after-overlap", [][]byte{firstLines(t, "testdata/event_v3.crx", 14), full}, body(t, converted.Bytes())},
+
+		// the event is dropped with the overlapping epochs
+		{"overlap", [][]byte{example, full}, body(t, exampleConverted.Bytes())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, crinex := range []bool{false, true} {
+				if got := body(t, spliced(t, tt.inputs, crinex)); !bytes.Equal(got, tt.want) {
+					t.Errorf("CRINEX=%v: output:\n%s\nwant:\n%s", crinex, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSpliceErrors(t *testing.T) {
+	var out bytes.Buffer
+	if err := Splice(&out, nil, SpliceOptions{}); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("no input: err = %v, want %v", err, ErrIncompatible)
+	}
+	if err := Splice(&out, []string{"testdata/no-such-file.crx"}, SpliceOptions{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing input: err = %v, want %v", err, os.ErrNotExist)
+	}
+
+	// RINEX 2 and 3
+	err := Splice(&out, []string{"testdata/example_v1.crx", "testdata/example_v3.crx"}, SpliceOptions{})
+	if !errors.Is(err, ErrIncompatible) {
+		t.Errorf("versions: err = %v, want %v", err, ErrIncompatible)
+	}
+	if out.Len() != 0 {
+		t.Errorf("output written for the incompatible inputs:\n%s", out.Bytes())
+	}
+}
@@ -0,0 +1,226 @@
+package crinex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+// ---------------------------------------------------
+// Splitting a file into pieces
+// ---------------------------------------------------
+
+// SplitOptions configures Split.
+type SplitOptions struct {
+	// Period is the length of the pieces, e.g. time.Hour. It must divide a
+	// day, and the pieces are aligned to the beginning of the day.
+	Period time.Duration
+
+	// CRINEX writes the pieces in Hatanaka RINEX instead of RINEX.
+	CRINEX bool
+
+	// Country is the ISO 3166 country code used in the file names if the
+	// marker name is not a 9 character name. "XXX" is used if empty.
+	Country string
+
+	// Source is the data source used in the file names, "R" (receiver) if
+	// empty.
+	Source string
+
+	// Create creates the file of a piece. The pieces are created in the
+	// current directory with os.Create if nil.
+	Create func(name string) (io.WriteCloser, error)
+}
+
+// Piece describes a file written by Split.
+type Piece struct {
+	Name   string    // file name in the RINEX 3 long name convention
+	First  time.Time // first epoch
+	Last   time.Time // last epoch
+	Epochs int       // number of epochs
+}
+
+// Split decodes the Hatanaka RINEX file read from r, and writes the epochs of
+// every period to a separate file, e.g. hourly files from a daily file.
+//
+// Each piece has the header of the input with TIME OF FIRST OBS (and TIME OF
+// LAST OBS if present) rewritten, and is named in the RINEX 3 long name
+// convention, e.g. "ABCD00JPN_R_20230010100_01H_30S_MO.crx". Pieces in
+// Hatanaka RINEX begin with an initialization epoch, so that every piece is
+// decoded on its own. The special events are written to the piece of the
+// epoch following them, or to the last piece at the end of the file. Epochs
+// earlier than the current piece are dropped.
+//
+// Split returns the pieces written, even if an error is returned.
+func Split(r io.Reader, opts SplitOptions) (pieces []Piece, err error) {
+	if opts.Period <= 0 || (24*time.Hour)%opts.Period != 0 {
+		return nil, fmt.Errorf("crinex: invalid period %v: must divide a day", opts.Period)
+	}
+	if opts.Create == nil {
+		opts.Create = func(name string) (io.WriteCloser, error) { return os.Create(name) }
+	}
+
+	s, err := NewScanner(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ParseHeader(); err != nil {
+		return nil, err
+	}
+	header, obsTypes := s.Header(), s.ObsTypes()
+
+	var (
+		p     *piece
+		start time.Time // start of the current piece
+	)
+	for s.ScanEpoch() {
+		if s.FileBoundary() && !equalObsTypes(obsTypes, s.ObsTypes()) {
+			return pieces, fmt.Errorf("%w: obstypes changed at line %d", ErrIncompatible, s.LineNumber())
+		}
+
+		epoch := s.Epoch()
+		if p != nil && epoch.Before(start) {
+			// backward to the earlier piece, and the special events are
+			// kept in the current piece
+			p.addEvents(s)
+			continue
+		}
+
+		if p == nil || !epoch.Before(start.Add(opts.Period)) {
+			if p != nil {
+				if err := p.write(opts.Create); err != nil {
+					return pieces, err
+				}
+				pieces = append(pieces, p.Piece)
+			}
+			start = epoch.Truncate(opts.Period)
+			p = newPiece(s.ver, header, start, opts)
+		}
+		p.addEvents(s)
+		p.add(s)
+	}
+	if err := s.Err(); err != nil {
+		return pieces, err
+	}
+
+	if p != nil {
+		// special events at the end of the file
+		p.addEvents(s)
+		if err := p.write(opts.Create); err != nil {
+			return pieces, err
+		}
+		pieces = append(pieces, p.Piece)
+	}
+	return pieces, nil
+}
+
+// piece holds the data of a piece until written.
+type piece struct {
+	Piece
+
+	header []byte
+	enc    *encoder // nil for RINEX
+	data   bytes.Buffer
+}
+
+func newPiece(ver string, header []byte, start time.Time, opts SplitOptions) *piece {
+	p := &piece{header: header}
+	p.Name = pieceName(header, start, opts)
+	if opts.CRINEX {
+		p.enc = newEncoder(ver)
+	}
+	return p
+}
+
+// add appends the current epoch of s to the piece.
+func (p *piece) add(s *Scanner) {
+	if p.enc != nil {
+		p.data.Write(p.enc.encode(s))
+	} else {
+		p.data.Write(s.EpochAsBytes())
+		p.data.Write(s.DataAsBytes())
+	}
+
+	if p.Epochs == 0 {
+		p.First = s.Epoch()
+	}
+	p.Last = s.Epoch()
+	p.Epochs++
+}
+
+// addEvents appends the special events of s preceding the current epoch, or
+// following the last epoch at the end of the file, to the piece.
+func (p *piece) addEvents(s *Scanner) {
+	if p.enc != nil {
+		p.data.Write(p.enc.events(s))
+	} else {
+		p.data.Write(s.EventsAsBytes())
+	}
+}
+
+// write creates the file of the piece, and writes the header and the data.
+func (p *piece) write(create func(string) (io.WriteCloser, error)) error {
+	lines := headerLines(p.header)
+
+	timeSys := ""
+	if l, ok := findHeaderLine(lines, labelFirstObs); ok {
+		_, timeSys, _ = parseObsTime(l)
+	}
+	lines = replaceHeaderLines(lines, labelFirstObs, labelEndOfHeader,
+		[]string{formatObsTime(p.First, timeSys, labelFirstObs)})
+	if _, ok := findHeaderLine(lines, labelLastObs); ok {
+		lines = replaceHeaderLines(lines, labelLastObs, labelEndOfHeader,
+			[]string{formatObsTime(p.Last, timeSys, labelLastObs)})
+	}
+
+	header := joinHeaderLines(lines)
+	if p.enc != nil {
+		header = p.enc.crinexHeader(header, time.Now())
+	}
+
+	w, err := create(p.Name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(header)
+	if err == nil {
+		_, err = p.data.WriteTo(w)
+	}
+	if e := w.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// equalObsTypes reports whether the obstypes are the same.
+func equalObsTypes(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for sys, codes := range a {
+		if !slices.Equal(codes, b[sys]) {
+			return false
+		}
+	}
+	return true
+}
+
+// pieceName returns the long file name of the piece starting at start, e.g.
+// "ABCD00JPN_R_20230010000_01H_30S_MO.crx".
+func pieceName(header []byte, start time.Time, opts SplitOptions) string {
+	f := NewFileName(header, start, opts.Period)
+	if f.Country == "" {
+		f.Country = opts.Country
+	}
+	f.Source = opts.Source
+	if opts.CRINEX {
+		f.Format = "crx"
+	}
+	return f.LongName()
+}
@@ -0,0 +1,134 @@
+package crinex
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// nopCloser is a bytes.Buffer closed as a file.
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestSplit(t *testing.T) {
+	b, err := os.ReadFile("testdata/example_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rnx := convertBytes(t, b)
+
+	for _, crinex := range []bool{false, true} {
+		files := make(map[string]*bytes.Buffer)
+		pieces, err := Split(bytes.NewReader(b), SplitOptions{
+			Period:  time.Minute,
+			CRINEX:  crinex,
+			Country: "JPN",
+			Create: func(name string) (io.WriteCloser, error) {
+				files[name] = new(bytes.Buffer)
+				return nopCloser{files[name]}, nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ext := "rnx"
+		if crinex {
+			ext = "crx"
+		}
+		want := []Piece{
+			{"TEST00JPN_R_20230010000_01M_30S_MO." + ext,
+				time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 1, 0, 0, 30, 0, time.UTC), 2},
+			{"TEST00JPN_R_20230010001_01M_30S_MO." + ext,
+				time.Date(2023, 1, 1, 0, 1, 30, 0, time.UTC), time.Date(2023, 1, 1, 0, 1, 30, 0, time.UTC), 1},
+		}
+		if len(pieces) != len(want) {
+			t.Fatalf("pieces = %+v, want %+v", pieces, want)
+		}
+
+		// the pieces are decoded on their own, and make up the input
+		var data []byte
+		for i, p := range pieces {
+			if p != want[i] {
+				t.Errorf("piece %d = %+v, want %+v", i, p, want[i])
+			}
+			f := files[p.Name].Bytes()
+			if crinex {
+				f = convertBytes(t, f)
+			}
+			data = append(data, body(t, f)...)
+		}
+		if !bytes.Equal(data, body(t, rnx)) {
+			t.Errorf("CRINEX=%v: data of the pieces:\n%s\nwant:\n%s", crinex, data, body(t, rnx))
+		}
+	}
+}
+
+func TestSplitEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string // special event at the end of the file
+	}{
+		{"testdata/event_v3.crx", "> 2023 01 01 00 02  0.0000000  4  1\n"},
+		{"testdata/event_v1.crx", "&99  6 12  0 16  0.0000000  4  1\n"},
+	}
+	for _, tt := range tests {
+		b, err := os.ReadFile(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b = append(b, tt.event+"END OF DATA                                                 COMMENT\n"...)
+		rnx := readerOutputBytes(t, b)
+		if !bytes.Contains(rnx, []byte("END OF DATA")) {
+			t.Fatalf("%s: the event at the end of the file not decoded", tt.name)
+		}
+
+		for _, crinex := range []bool{false, true} {
+			files := make(map[string]*bytes.Buffer)
+			pieces, err := Split(bytes.NewReader(b), SplitOptions{
+				Period: time.Minute,
+				CRINEX: crinex,
+				Create: func(name string) (io.WriteCloser, error) {
+					files[name] = new(bytes.Buffer)
+					return nopCloser{files[name]}, nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pieces) != 2 {
+				t.Fatalf("%s: pieces = %+v, want 2", tt.name, pieces)
+			}
+
+			// the pieces joined together make up the input with the events
+			var data []byte
+			for _, p := range pieces {
+				f := files[p.Name].Bytes()
+				if crinex {
+					f = readerOutputBytes(t, f)
+				}
+				data = append(data, body(t, f)...)
+			}
+			if !bytes.Equal(data, body(t, rnx)) {
+				t.Errorf("%s CRINEX=%v: data of the pieces:\n%s\nwant:\n%s", tt.name, crinex, data, body(t, rnx))
+			}
+		}
+	}
+}
+
+// readerOutputBytes returns the RINEX decoded by NewReader from b.
+func readerOutputBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -0,0 +1,205 @@
+package crinex
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ---------------------------------------------------
+// Slant TEC
+// ---------------------------------------------------
+
+// tecuPerMeter is 1/40.3 in TECU (1e16 electrons/m^2): the ionospheric delay
+// in meters at the frequency f is 40.3 TEC / f^2.
+const tecuPerMeter = 1 / 40.3 / 1e16
+
+// TECOptions configures SlantTEC.
+type TECOptions struct {
+	// MaxGap is the MaxGap of the ArcTracker that follows the phases.
+	MaxGap time.Duration
+
+	// MinArc is the minimum number of epochs of an arc (default: 10).
+	// Shorter arcs are discarded.
+	MinArc int
+
+	// Slip configures the cycle slip detection that breaks the arcs.
+	Slip SlipOptions
+}
+
+// TECArc is the slant TEC of a continuous arc of a satellite.
+type TECArc struct {
+	Sat    string     `json:"sat"`
+	Phases [2]string  `json:"phases"` // phase observations, e.g. ["L1C", "L2W"]
+	Codes  [2]string  `json:"codes"`  // code observations, e.g. ["C1C", "C2W"]
+	Start  time.Time  `json:"start"`  // first epoch, in UTC
+	End    time.Time  `json:"end"`    // last epoch, in UTC
+	Offset float64    `json:"offset"` // leveling offset added to the phase TEC, in TECU
+	Values []TECValue `json:"values"`
+}
+
+// TECValue is the slant TEC at an epoch, in TECU.
+type TECValue struct {
+	Epoch time.Time `json:"epoch"` // in UTC, see SysTime.UTC
+	STEC  float64   `json:"stec"`  // phase TEC leveled to the code TEC
+	Phase float64   `json:"phase"` // phase TEC, with the ambiguities
+	Code  float64   `json:"code"`  // code TEC
+}
+
+// SlantTEC decodes the Hatanaka RINEX file read from r, and computes the
+// slant TEC of every satellite from the phase and code observations of the
+// first pair of the bands used for the cycle slip detection, e.g. L1 and L2
+// for GPS:
+//
+//	TEC = f1^2 f2^2 / (40.3 (f1^2 - f2^2)) (P2 - P1)          code
+//	TEC = f1^2 f2^2 / (40.3 (f1^2 - f2^2)) (λ1 L1 - λ2 L2)    phase
+//
+// The phase TEC is leveled to the code TEC by adding the mean difference of
+// the two over each arc of the combination, see comboTracker. The TEC is not
+// calibrated: it includes the differential code biases of the satellite and
+// the receiver. The epochs are converted to UTC from the time system of the
+// file.
+//
+// The frequencies of the GLONASS FDMA bands are given by the GLONASS SLOT /
+// FRQ # header; GLONASS satellites without the channel are skipped.
+func SlantTEC(r io.Reader, opts TECOptions) (arcs []TECArc, err error) {
+	s, err := NewScanner(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ParseHeader(); err != nil {
+		return nil, err
+	}
+	if opts.MinArc <= 0 {
+		opts.MinArc = defaultMinArc
+	}
+
+	tracker := newComboTracker(opts.MaxGap, opts.MinArc, opts.Slip)
+	addArcs := func(ended []*comboArc) {
+		for _, a := range ended {
+			offset := 0.0
+			for _, v := range a.values {
+				offset += v[1] - v[0]
+			}
+			offset /= float64(len(a.values))
+
+			values := make([]TECValue, len(a.values))
+			for i, v := range a.values {
+				values[i] = TECValue{Epoch: a.epochs[i], STEC: v[0] + offset, Phase: v[0], Code: v[1]}
+			}
+			arcs = append(arcs, TECArc{
+				Sat: a.key.sat, Phases: a.key.phases, Codes: a.key.codes,
+				Start: a.epochs[0], End: a.epochs[len(a.epochs)-1],
+				Offset: offset, Values: values,
+			})
+		}
+	}
+
+	for s.ScanEpoch() {
+		obs, ended := tracker.update(s)
+		addArcs(ended)
+
+		epoch := s.SysEpoch().UTC()
+		for _, o := range obs {
+			if len(o.ObsData) == 0 {
+				continue
+			}
+			sys := o.SatId[:1]
+			if sys == " " {
+				sys = "G"
+			}
+			codes := s.ObsTypes()[o.SatId[:1]]
+
+			c, ok := tecCombination(o, codes, sys, tracker.freq)
+			if !ok {
+				continue
+			}
+			key := comboKey{
+				sat:    o.SatId,
+				phases: [2]string{codes[c.phase[0]], codes[c.phase[1]]},
+				codes:  [2]string{codes[c.code[0]], codes[c.code[1]]},
+			}
+			tracker.add(key, epoch, [2]float64{c.phaseTEC, c.codeTEC})
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	addArcs(tracker.flush())
+
+	sort.Slice(arcs, func(i, j int) bool {
+		a, b := arcs[i], arcs[j]
+		if a.Sat != b.Sat {
+			return a.Sat < b.Sat
+		}
+		return a.Start.Before(b.Start)
+	})
+	return arcs, nil
+}
+
+// tecObs is the slant TEC combination observed at an epoch.
+type tecObs struct {
+	phase    [2]int // indices of the phase observations of the bands 1 and 2
+	code     [2]int // indices of the code observations of the bands 1 and 2
+	phaseTEC float64
+	codeTEC  float64
+}
+
+// tecCombination returns the slant TEC of the first pair of the bands in
+// slipBands observed with the phases and codes of both bands.
+func tecCombination(o SatObss, codes []string, sys string, freq FreqTable) (c tecObs, ok bool) {
+	for _, bands := range slipBands[sys] {
+		l1, c1 := signalIndices(o, codes, bands[0])
+		l2, c2 := signalIndices(o, codes, bands[1])
+		if l1 < 0 || c1 < 0 || l2 < 0 || c2 < 0 {
+			continue
+		}
+		f1, ok1 := freq.Frequency(o.SatId, codes[l1])
+		f2, ok2 := freq.Frequency(o.SatId, codes[l2])
+		if !ok1 || !ok2 {
+			continue
+		}
+
+		k := f1 * f1 * f2 * f2 / (f1*f1 - f2*f2) * tecuPerMeter
+		ph1 := o.ObsData[l1].Data * SpeedOfLight / f1
+		ph2 := o.ObsData[l2].Data * SpeedOfLight / f2
+		return tecObs{
+			phase:    [2]int{l1, l2},
+			code:     [2]int{c1, c2},
+			phaseTEC: k * (ph1 - ph2),
+			codeTEC:  k * (o.ObsData[c2].Data - o.ObsData[c1].Data),
+		}, true
+	}
+	return c, false
+}
+
+// WriteTECCSV writes the slant TEC of the arcs in CSV, with the header
+// "sat,phases,codes,arc,epoch,stec,phase,code". arc is the index of the arc
+// in arcs, the epoch is in UTC in RFC 3339, and the phases and codes are
+// joined by "/", e.g. "L1C/L2W".
+func WriteTECCSV(w io.Writer, arcs []TECArc) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"sat", "phases", "codes", "arc", "epoch", "stec", "phase", "code"}); err != nil {
+		return err
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for i, a := range arcs {
+		for _, v := range a.Values {
+			rec := []string{
+				a.Sat,
+				a.Phases[0] + "/" + a.Phases[1],
+				a.Codes[0] + "/" + a.Codes[1],
+				strconv.Itoa(i),
+				v.Epoch.UTC().Format(time.RFC3339Nano),
+				format(v.STEC), format(v.Phase), format(v.Code),
+			}
+			if err := cw.Write(rec); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
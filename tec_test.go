@@ -0,0 +1,144 @@
+package crinex
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlantTEC(t *testing.T) {
+	f, err := os.Open("testdata/slip_l5.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	arcs, err := SlantTEC(f, TECOptions{MinArc: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// L1/L2 of every satellite, not broken by the slip of L5Q of G01
+	want := []struct {
+		sat  string
+		stec float64 // at the first epoch
+	}{
+		{"G01", 30.716},
+		{"G02", 49.256},
+	}
+	if len(arcs) != len(want) {
+		t.Fatalf("arcs = %+v", arcs)
+	}
+	// the epochs in GPS time are converted to UTC
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Add(-18 * time.Second)
+	for i, a := range arcs {
+		if a.Sat != want[i].sat || a.Phases != [2]string{"L1C", "L2W"} || a.Codes != [2]string{"C1C", "C2W"} ||
+			!a.Start.Equal(start) || !a.End.Equal(start.Add(570*time.Second)) || len(a.Values) != 20 {
+			t.Errorf("arc %d = %s %v %v %v-%v with %d values", i, a.Sat, a.Phases, a.Codes, a.Start, a.End, len(a.Values))
+			continue
+		}
+		if d := a.Values[0].STEC - want[i].stec; math.Abs(d) > 0.001 {
+			t.Errorf("%s: STEC = %.3f, want %.3f", a.Sat, a.Values[0].STEC, want[i].stec)
+		}
+
+		// the phase TEC leveled to the code TEC over the arc
+		var mean float64
+		for _, v := range a.Values {
+			if d := v.STEC - v.Phase - a.Offset; math.Abs(d) > 1e-9 {
+				t.Errorf("%s %v: STEC - phase = %g, want the offset %g", a.Sat, v.Epoch, v.STEC-v.Phase, a.Offset)
+			}
+			mean += (v.STEC - v.Code) / float64(len(a.Values))
+		}
+		if math.Abs(mean) > 1e-9 {
+			t.Errorf("%s: mean of STEC - code = %g, want 0", a.Sat, mean)
+		}
+	}
+
+	// the default MinArc discards no arcs of 20 epochs
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if arcs, err := SlantTEC(f, TECOptions{}); err != nil || len(arcs) != 2 {
+		t.Errorf("arcs = %d, err = %v, want 2 arcs", len(arcs), err)
+	}
+}
+
+func TestSlantTECGlonass(t *testing.T) {
+	f, err := os.Open("testdata/multi_v3.crx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	arcs, err := SlantTEC(f, TECOptions{MinArc: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the true TEC of the data at the first epoch and its rate in TECU/s,
+	// R10 without the channel is skipped
+	want := []struct {
+		sat        string
+		phases     [2]string
+		tec0, rate float64
+	}{
+		{"C01", [2]string{"L2I", "L7I"}, 35, -0.001},
+		{"E01", [2]string{"L1C", "L5Q"}, 15, 0.001},
+		{"G01", [2]string{"L1C", "L2W"}, 20, 0.002},
+		{"J01", [2]string{"L1C", "L2L"}, 18, 0.0015},
+		{"R01", [2]string{"L1C", "L2P"}, 30, 0.001},
+		{"R02", [2]string{"L1C", "L2P"}, 12, -0.002},
+		{"R09", [2]string{"L1C", "L2P"}, 40, 0.003},
+	}
+	if len(arcs) != len(want) {
+		t.Fatalf("%d arcs, want %d: %+v", len(arcs), len(want), arcs)
+	}
+	for i, a := range arcs {
+		w := want[i]
+		if a.Sat != w.sat || a.Phases != w.phases || len(a.Values) != 20 {
+			t.Errorf("arc %d = %s %v with %d values, want %s %v with 20 values", i, a.Sat, a.Phases, len(a.Values), w.sat, w.phases)
+			continue
+		}
+		for k, v := range a.Values {
+			tec := w.tec0 + w.rate*30*float64(k)
+			if math.Abs(v.STEC-tec) > 0.02 {
+				t.Errorf("%s %v: STEC = %.3f, want %.3f", a.Sat, v.Epoch, v.STEC, tec)
+				break
+			}
+		}
+	}
+}
+
+func TestWriteTECCSV(t *testing.T) {
+	t0 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	arcs := []TECArc{
+		{Sat: "G01", Phases: [2]string{"L1C", "L2W"}, Codes: [2]string{"C1C", "C2W"}, Values: []TECValue{
+			{Epoch: t0, STEC: 30.7157, Phase: -2807.2687, Code: 30.996},
+			{Epoch: t0.Add(30 * time.Second), STEC: 30.7537, Phase: -2807.2308, Code: 29.2253},
+		}},
+		{Sat: "R03", Phases: [2]string{"L1C", "L2C"}, Codes: [2]string{"C1C", "C2C"}, Values: []TECValue{
+			{Epoch: t0.Add(500 * time.Millisecond), STEC: -1, Phase: 2, Code: 0.0004},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTECCSV(&buf, arcs); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"sat,phases,codes,arc,epoch,stec,phase,code",
+		"G01,L1C/L2W,C1C/C2W,0,2023-01-01T00:00:00Z,30.716,-2807.269,30.996",
+		"G01,L1C/L2W,C1C/C2W,0,2023-01-01T00:00:30Z,30.754,-2807.231,29.225",
+		"R03,L1C/L2C,C1C/C2C,1,2023-01-01T00:00:00.5Z,-1.000,2.000,0.000",
+	}, "\n") + "\n"
+	if buf.String() != want {
+		t.Errorf("WriteTECCSV:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	if err := WriteTECCSV(errWriter{}, arcs); err == nil {
+		t.Errorf("WriteTECCSV to a failing writer succeeded")
+	}
+}
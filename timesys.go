@@ -0,0 +1,218 @@
+package crinex
+
+import (
+	"strconv"
+	"time"
+)
+
+// ---------------------------------------------------
+// GNSS time systems
+// ---------------------------------------------------
+
+// TimeSystem is the time system of the epochs, given by the TIME OF FIRST OBS
+// header, e.g. "GPS".
+type TimeSystem string
+
+const (
+	TimeGPS TimeSystem = "GPS" // GPS time
+	TimeGLO TimeSystem = "GLO" // UTC(SU), used for GLONASS in RINEX
+	TimeGAL TimeSystem = "GAL" // Galileo system time, aligned to GPS time
+	TimeBDT TimeSystem = "BDT" // BeiDou time, GPS time - 14 s
+	TimeQZS TimeSystem = "QZS" // QZSS time, aligned to GPS time
+	TimeIRN TimeSystem = "IRN" // NavIC time, aligned to GPS time
+	TimeUTC TimeSystem = "UTC" // UTC
+)
+
+var (
+	gpsEpoch = time.Date(1980, 1, 6, 0, 0, 0, 0, time.UTC) // beginning of GPS week 0
+	bdsEpoch = time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC) // beginning of BDS week 0, in BDT
+)
+
+// bdtOffset is GPS time - BDT.
+const bdtOffset = 14 * time.Second
+
+// weekDuration is the length of a week.
+const weekDuration = 7 * 24 * time.Hour
+
+// leapSecondTable is the dates in UTC when GPS time - UTC was increased to
+// the index + 1 seconds.
+var leapSecondTable = []time.Time{
+	time.Date(1981, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1982, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1983, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1985, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1988, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1991, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1992, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1993, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1994, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1996, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1997, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2009, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2012, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// LeapSeconds returns GPS time - UTC in seconds at the UTC time t, given by
+// the built-in leap second table.
+func LeapSeconds(t time.Time) int {
+	n := 0
+	for _, d := range leapSecondTable {
+		if t.Before(d) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// leapSecondsGPS returns GPS time - UTC in seconds at the GPS time t, given by
+// the built-in leap second table.
+func leapSecondsGPS(t time.Time) int {
+	n := 0
+	for i, d := range leapSecondTable {
+		// the leap second takes effect at d + (i+1) seconds in GPS time
+		if t.Before(d.Add(time.Duration(i+1) * time.Second)) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// SysTime is a time tag in a time system. The calendar fields of Time are
+// those of the time system, and the location of Time (UTC) has no meaning.
+type SysTime struct {
+	Time   time.Time
+	System TimeSystem
+
+	// Leap is GPS time - UTC in seconds given by the LEAP SECONDS header,
+	// used if LeapSet is true. Otherwise the built-in leap second table is
+	// used.
+	Leap    int
+	LeapSet bool
+}
+
+// GPS returns the time tag converted to GPS time.
+// Unknown time systems are taken as GPS time.
+func (t SysTime) GPS() time.Time {
+	switch t.System {
+	case TimeBDT:
+		return t.Time.Add(bdtOffset)
+	case TimeGLO, TimeUTC:
+		leap := t.Leap
+		if !t.LeapSet {
+			leap = LeapSeconds(t.Time)
+		}
+		return t.Time.Add(time.Duration(leap) * time.Second)
+	}
+	return t.Time
+}
+
+// UTC returns the time tag converted to UTC.
+func (t SysTime) UTC() time.Time {
+	switch t.System {
+	case TimeGLO, TimeUTC:
+		return t.Time
+	}
+
+	gps := t.GPS()
+	leap := t.Leap
+	if !t.LeapSet {
+		leap = leapSecondsGPS(gps)
+	}
+	return gps.Add(-time.Duration(leap) * time.Second)
+}
+
+// BDT returns the time tag converted to BeiDou time.
+func (t SysTime) BDT() time.Time {
+	return t.GPS().Add(-bdtOffset)
+}
+
+// GPSWeek returns the GPS week and the time of week in seconds.
+func (t SysTime) GPSWeek() (week int, tow float64) {
+	return weekTime(t.GPS(), gpsEpoch)
+}
+
+// BDSWeek returns the BDS week and the time of week in seconds.
+func (t SysTime) BDSWeek() (week int, tow float64) {
+	return weekTime(t.BDT(), bdsEpoch)
+}
+
+// weekTime returns the weeks and the seconds of the week elapsed from origin.
+func weekTime(t, origin time.Time) (w int, tow float64) {
+	d := t.Sub(origin)
+	w = int(d / weekDuration)
+	if d%weekDuration < 0 {
+		w--
+	}
+	return w, (d - time.Duration(w)*weekDuration).Seconds()
+}
+
+// defaultTimeSystem returns the time system of the epochs when TIME OF FIRST
+// OBS has no time system: GPS for GPS and mixed files, or the system time of
+// a single-system file.
+func defaultTimeSystem(sys string) TimeSystem {
+	switch sys {
+	case "R":
+		return TimeGLO
+	case "E":
+		return TimeGAL
+	case "C":
+		return TimeBDT
+	case "J":
+		return TimeQZS
+	case "I":
+		return TimeIRN
+	}
+	return TimeGPS
+}
+
+// headerTimeSystem returns the time system of the epochs and the leap seconds
+// (GPS time - UTC) given by the header lines. ok is false if LEAP SECONDS is
+// not given.
+func headerTimeSystem(lines []string) (ts TimeSystem, leap int, ok bool) {
+	if l, ok := findHeaderLine(lines, labelFirstObs); ok {
+		if _, sys, err := parseObsTime(l); err == nil && sys != "" {
+			ts = TimeSystem(sys)
+		}
+	}
+	if ts == "" {
+		sys := ""
+		if l, ok := findHeaderLine(lines, labelRinexVersion); ok {
+			sys = trimHeaderValue(l, 40, 41)
+		}
+		ts = defaultTimeSystem(sys)
+	}
+
+	if l, found := findHeaderLine(lines, labelLeapSeconds); found {
+		if n, err := strconv.Atoi(trimHeaderValue(l, 0, 6)); err == nil {
+			leap, ok = n, true
+			if trimHeaderValue(l, 24, 27) == "BDS" {
+				// BDT - UTC
+				leap += int(bdtOffset / time.Second)
+			}
+		}
+	}
+	return ts, leap, ok
+}
+
+// TimeSystem returns the time system of the epochs, given by TIME OF FIRST OBS
+// or the satellite system of the file.
+func (s *Scanner) TimeSystem() TimeSystem {
+	return s.SysEpoch().System
+}
+
+// SysEpoch returns the time tag of the current epoch in the time system of
+// the file. The leap seconds are given by the LEAP SECONDS header, or by the
+// built-in table if not given.
+func (s *Scanner) SysEpoch() SysTime {
+	if s.timeSys == "" {
+		s.timeSys, s.leap, s.leapSet = headerTimeSystem(headerLines(s.header))
+	}
+	return SysTime{Time: s.epoch, System: s.timeSys, Leap: s.leap, LeapSet: s.leapSet}
+}
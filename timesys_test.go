@@ -0,0 +1,118 @@
+package crinex
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeapSeconds(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want int
+	}{
+		{time.Date(1980, 1, 6, 0, 0, 0, 0, time.UTC), 0},
+		{time.Date(1999, 6, 12, 0, 0, 0, 0, time.UTC), 13},
+		{time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC), 17},
+		{time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), 18},
+	}
+	for _, tt := range tests {
+		if got := LeapSeconds(tt.t); got != tt.want {
+			t.Errorf("LeapSeconds(%v) = %d, want %d", tt.t, got, tt.want)
+		}
+	}
+
+	// the leap second of 2017 takes effect at 00:00:18 in GPS time
+	if n := leapSecondsGPS(time.Date(2017, 1, 1, 0, 0, 17, 0, time.UTC)); n != 17 {
+		t.Errorf("leapSecondsGPS before the leap second = %d", n)
+	}
+	if n := leapSecondsGPS(time.Date(2017, 1, 1, 0, 0, 18, 0, time.UTC)); n != 18 {
+		t.Errorf("leapSecondsGPS after the leap second = %d", n)
+	}
+}
+
+func TestSysTime(t *testing.T) {
+	t0 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		st      SysTime
+		gps     time.Time
+		utc     time.Time
+		week    int
+		tow     float64
+		bdsWeek int
+		bdsTow  float64
+	}{
+		{"GPS", SysTime{Time: t0, System: TimeGPS}, t0, t0.Add(-18 * time.Second), 2243, 0, 886, 604786},
+		{"GAL", SysTime{Time: t0, System: TimeGAL}, t0, t0.Add(-18 * time.Second), 2243, 0, 886, 604786},
+		{"BDT", SysTime{Time: t0, System: TimeBDT}, t0.Add(14 * time.Second), t0.Add(-4 * time.Second), 2243, 14, 887, 0},
+		{"GLO", SysTime{Time: t0, System: TimeGLO}, t0.Add(18 * time.Second), t0, 2243, 18, 887, 4},
+		{"UTC", SysTime{Time: t0, System: TimeUTC}, t0.Add(18 * time.Second), t0, 2243, 18, 887, 4},
+		{"UTC/leap", SysTime{Time: t0, System: TimeUTC, Leap: 17, LeapSet: true}, t0.Add(17 * time.Second), t0, 2243, 17, 887, 3},
+		{"UTC/leap0", SysTime{Time: t0, System: TimeUTC, LeapSet: true}, t0, t0, 2243, 0, 886, 604786},
+		{"GPS/leap", SysTime{Time: t0, System: TimeGPS, Leap: 17, LeapSet: true}, t0, t0.Add(-17 * time.Second), 2243, 0, 886, 604786},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.st.GPS(); !got.Equal(tt.gps) {
+				t.Errorf("GPS() = %v, want %v", got, tt.gps)
+			}
+			if got := tt.st.UTC(); !got.Equal(tt.utc) {
+				t.Errorf("UTC() = %v, want %v", got, tt.utc)
+			}
+			if got := tt.st.BDT(); !got.Equal(tt.gps.Add(-14 * time.Second)) {
+				t.Errorf("BDT() = %v", got)
+			}
+			if w, tow := tt.st.GPSWeek(); w != tt.week || tow != tt.tow {
+				t.Errorf("GPSWeek() = %d %g, want %d %g", w, tow, tt.week, tt.tow)
+			}
+			if w, tow := tt.st.BDSWeek(); w != tt.bdsWeek || tow != tt.bdsTow {
+				t.Errorf("BDSWeek() = %d %g, want %d %g", w, tow, tt.bdsWeek, tt.bdsTow)
+			}
+		})
+	}
+}
+
+func TestScannerTimeSystem(t *testing.T) {
+	const leap = "    18    18  2185     7                                    LEAP SECONDS\n"
+	firstObs := "  2023     1     1     0     0    0.0000000     GPS         TIME OF FIRST OBS\n"
+
+	tests := []struct {
+		name     string
+		file     string
+		old, new string // replaced in the file, if old is not empty
+		ts       TimeSystem
+		leap     int
+		leapSet  bool
+	}{
+		{"first-obs", "testdata/example_v3.crx", "", "", TimeGPS, 0, false},
+		{"glo-leap", "testdata/example_v3.crx", firstObs, strings.Replace(firstObs, "GPS", "GLO", 1) + leap, TimeGLO, 18, true},
+		{"no-first-obs", "testdata/example_v3.crx", firstObs, "", TimeGPS, 0, false},
+		{"single-system", "testdata/example_v1.crx", "", "", TimeGPS, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.old != "" {
+				b = corrupted(t, tt.file, tt.old, tt.new)
+			}
+			s, err := NewScanner(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !s.ScanEpoch() {
+				t.Fatal(s.Err())
+			}
+			st := s.SysEpoch()
+			if s.TimeSystem() != tt.ts || st.System != tt.ts || st.Leap != tt.leap || st.LeapSet != tt.leapSet || !st.Time.Equal(s.Epoch()) {
+				t.Errorf("SysEpoch() = %+v, want %s with leap %d %v", st, tt.ts, tt.leap, tt.leapSet)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package crinex
+
+import (
+	"fmt"
+	"time"
+)
+
+// Truncation describes the last epoch of a file that ends in the middle of
+// the epoch, e.g. a partially transferred file.
+type Truncation struct {
+	EpochLine int       // line number of the epoch record of the truncated epoch
+	Line      int       // line number of the last line in the file
+	Epoch     time.Time // time tag of the truncated epoch, zero if unknown
+	Expected  int       // number of satellites in the epoch record
+	Decoded   int       // number of satellites decoded
+}
+
+// Lost returns the number of satellites lost in the truncated epoch.
+func (t Truncation) Lost() int {
+	return t.Expected - t.Decoded
+}
+
+// TruncatedError is returned when the file ends in the middle of an epoch.
+type TruncatedError struct {
+	Truncation
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("%v: epoch at line %d ends at line %d: %d of %d satellites lost",
+		ErrTruncated, e.EpochLine, e.Line, e.Lost(), e.Expected)
+}
+
+// Unwrap returns ErrTruncated.
+func (e *TruncatedError) Unwrap() error {
+	return ErrTruncated
+}
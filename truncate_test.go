@@ -0,0 +1,107 @@
+package crinex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// firstLines returns the first n lines of the file.
+func firstLines(t *testing.T, name string, n int) []byte {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(b, []byte("\n"))
+	if n > len(lines) {
+		t.Fatalf("%s has %d lines", name, len(lines))
+	}
+	return bytes.Join(lines[:n], nil)
+}
+
+func TestTruncated(t *testing.T) {
+	epoch2 := time.Date(2023, 1, 1, 0, 0, 30, 0, time.UTC)
+	event := time.Date(2023, 1, 1, 0, 1, 0, 0, time.UTC)
+
+	// lines of event_v3.crx: epoch 1 at 10-14, epoch 2 at 15-19, the event
+	// at 20-21 and epoch 3 at 22-26
+	const v3 = "testdata/event_v3.crx"
+	tests := []struct {
+		file   string
+		lines  int
+		epochs int // epochs decoded including the truncated one
+		want   Truncation
+	}{
+		{v3, 15, 1, Truncation{EpochLine: 15, Line: 15, Epoch: epoch2, Expected: 3}},
+		{v3, 16, 1, Truncation{EpochLine: 15, Line: 16, Epoch: epoch2, Expected: 3}},
+		{v3, 17, 2, Truncation{EpochLine: 15, Line: 17, Epoch: epoch2, Expected: 3, Decoded: 1}},
+		{v3, 18, 2, Truncation{EpochLine: 15, Line: 18, Epoch: epoch2, Expected: 3, Decoded: 2}},
+		{v3, 20, 2, Truncation{EpochLine: 20, Line: 20, Epoch: event}},
+
+		// the epoch record of CRINEX 1.0 at line 7
+		{"testdata/event_v1.crx", 7, 0, Truncation{EpochLine: 7, Line: 7,
+			Epoch: time.Date(1999, 6, 12, 0, 14, 0, 0, time.UTC), Expected: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s:%d", filepath.Base(tt.file), tt.lines), func(t *testing.T) {
+			b := firstLines(t, tt.file, tt.lines)
+
+			// Scanner
+			var out bytes.Buffer
+			epochs, _, err := Convert(&out, bytes.NewReader(b))
+			checkTruncated(t, "Scanner", err, tt.want)
+			if epochs != tt.epochs {
+				t.Errorf("epochs = %d, want %d", epochs, tt.epochs)
+			}
+
+			// Reader
+			r, err := NewReader(bytes.NewReader(b))
+			checkTruncated(t, "Reader", err, tt.want)
+			got, _ := io.ReadAll(r)
+			if !bytes.Equal(got, out.Bytes()) {
+				t.Errorf("Reader and Scanner differ:\n%s\nwant:\n%s", got, out.Bytes())
+			}
+		})
+	}
+}
+
+// checkTruncated checks that err is a *TruncatedError of want.
+func checkTruncated(t *testing.T, path string, err error, want Truncation) {
+	t.Helper()
+	var te *TruncatedError
+	if !errors.As(err, &te) || !errors.Is(err, ErrTruncated) {
+		t.Fatalf("%s: err = %v, want *TruncatedError", path, err)
+	}
+	if te.Truncation != want {
+		t.Errorf("%s: truncation = %+v, want %+v", path, te.Truncation, want)
+	}
+}
+
+func TestTruncatedEpochShrunk(t *testing.T) {
+	b := firstLines(t, "testdata/example_v1.crx", 13)
+
+	s, err := NewScanner(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last []byte
+	for s.ScanEpoch() {
+		last = s.EpochAsBytes()
+	}
+
+	// the second epoch is shrunk to G01
+	want := " 99  6 12  0 14 30.0000000  0  1G01                                  0.000123466\n"
+	if string(last) != want {
+		t.Errorf("epoch = %q, want %q", last, want)
+	}
+	if tr, ok := s.Truncated(); !ok || tr.Lost() != 1 {
+		t.Errorf("Truncated() = %+v, %v", tr, ok)
+	}
+}
@@ -1,8 +1,143 @@
 package crinex
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Severity represents the severity of a Diagnostic.
+type Severity int
+
+const (
+	SeverityInfo    Severity = iota // informational, e.g. a file boundary
+	SeverityWarning                 // the record was repaired or skipped
+	SeverityError                   // the record could not be decoded
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Code is a stable identifier of a Diagnostic that can be matched
+// programmatically. The message of a Diagnostic may change, but the code does
+// not.
+type Code string
+
+const (
+	// header
+	CodeNoHeaderLabel   Code = "no-header-label"  // header line without label, read as a comment
+	CodeInvalidObsTypes Code = "invalid-obstypes" // obstypes header could not be parsed
+	CodeFileBoundary    Code = "file-boundary"    // new file begins in the concatenated stream
+
+	// epoch record
+	CodeInvalidEpoch     Code = "invalid-epoch"         // epoch could not be decoded
+	CodeEpochRecModified Code = "epoch-record-modified" // epoch record was cut at an initialization flag
+	CodeResync           Code = "resync"                // lines were skipped to the next initialization flag
+	CodeEpochRecLength   Code = "epoch-record-length"   // length of epoch record is inconsistent with the number of satellites
+	CodeSatListRepaired  Code = "satlist-repaired"      // satellite list was rearranged
+	CodeNumSatMismatch   Code = "numsat-mismatch"       // number of satellites differs from the satellite list
+	CodeSatIDRepaired    Code = "satid-repaired"        // invalid satellite ID was repaired
+	CodeInvalidPicoSec   Code = "invalid-picosec"       // non-numeric entries in the pico-second record
+	CodeDuplicateEpoch   Code = "duplicate-epoch"       // epoch has the same time tag as the previous one
+	CodeEpochBackward    Code = "epoch-backward"        // epoch is earlier than the previous one
+	CodeEpochGap         Code = "epoch-gap"             // epochs are missing before the epoch (Options.CheckSampling)
+	CodeEpochOffGrid     Code = "epoch-off-grid"        // epoch is not on the sampling grid (Options.CheckSampling)
+
+	// data block
+	CodeInvalidSatellite Code = "invalid-satellite" // satellite with invalid ID was ignored
+	CodeUnknownSatSys    Code = "unknown-satsys"    // satellite system not defined in obstypes
+	CodeValueOverflow    Code = "value-overflow"    // value does not fit in the RINEX format F14.3
+)
+
+// Diagnostic describes an issue found while decoding.
+type Diagnostic struct {
+	Code     Code
+	Severity Severity
+	Pos      int       // line number, 0 if unknown
+	Sat      string    // satellite involved, empty if none
+	Epoch    time.Time // epoch involved, zero if unknown
+	Line     string    // original line or record
+	Msg      string
+}
+
+func (d Diagnostic) String() string {
+	msg := d.Msg
+	if d.Code != "" {
+		msg = fmt.Sprintf("%s: %s", d.Code, d.Msg)
+	}
+	if d.Pos > 0 {
+		return fmt.Sprintf("pos:%d, msg:%s", d.Pos, msg)
+	}
+	return msg
+}
+
+// ValidationError is returned in the strict mode when the input would be
+// repaired or skipped. The Diagnostic holds the line number and the original
+// line.
+type ValidationError struct {
+	Diagnostic
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line == "" {
+		return fmt.Sprintf("%v: line %d: %s: %s", ErrStrict, e.Pos, e.Code, e.Msg)
+	}
+	return fmt.Sprintf("%v: line %d: %s: %s: '%s'", ErrStrict, e.Pos, e.Code, e.Msg, e.Line)
+}
+
+// Unwrap returns ErrStrict.
+func (e *ValidationError) Unwrap() error {
+	return ErrStrict
+}
+
+// level returns the slog.Level corresponding to the severity.
+func (s Severity) level() slog.Level {
+	switch s {
+	case SeverityInfo:
+		return slog.LevelInfo
+	case SeverityWarning:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+// logDiagnostic writes d to logger with structured attributes.
+// Nothing is written if logger is nil.
+func logDiagnostic(logger *slog.Logger, d Diagnostic) {
+	if logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("code", string(d.Code)),
+		slog.Int("line", d.Pos),
+	}
+	if d.Sat != "" {
+		attrs = append(attrs, slog.String("sat", d.Sat))
+	}
+	if !d.Epoch.IsZero() {
+		attrs = append(attrs, slog.Time("epoch", d.Epoch))
+	}
+	logger.LogAttrs(context.Background(), d.Severity.level(), d.Msg, attrs...)
+}
 
 // Warning stores a warning message and the line number where the warning raised.
+// See Diagnostic for the code, the severity and the other details.
 type Warning struct {
 	Pos int
 	Msg string
@@ -15,10 +150,16 @@ func (w Warning) String() string {
 	return w.Msg
 }
 
+// Warning returns the Warning of the diagnostic, with the line number and the
+// message.
+func (d Diagnostic) Warning() *Warning {
+	return &Warning{Pos: d.Pos, Msg: d.Msg}
+}
+
 // WarningList is a list of *Warning.
 type WarningList []*Warning
 
-// Add adds an [Error] with given position and error message to an [ErrorList].
+// Add adds a Warning with given position and message to the WarningList.
 func (p *WarningList) Add(pos int, msg string) {
 	*p = append(*p, &Warning{pos, msg})
 }